@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// AuthorProfile 用于存储 authors.config 中的作者信息
+type AuthorProfile struct {
+	ID     string       `yaml:"-"`
+	Name   string       `yaml:"name"`
+	Bio    string       `yaml:"bio"`
+	Avatar string       `yaml:"avatar"`
+	Email  string       `yaml:"email"`
+	Links  []AuthorLink `yaml:"links"`
+}
+
+type AuthorLink struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// authorIDPattern 限制作者 ID 的字符，ID 会用作 /authors/<id>/ 目录名
+var authorIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type authorsFile struct {
+	Authors map[string]*AuthorProfile `yaml:"authors"`
+}
+
+// LoadAuthors 读取作者数据文件，返回作者 ID 到作者信息的映射
+func LoadAuthors(filePath string) (map[string]*AuthorProfile, error) {
+	authors := make(map[string]*AuthorProfile)
+
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return authors, nil
+		}
+		return nil, err
+	}
+
+	var file authorsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析作者文件失败: %v", err)
+	}
+
+	for id, author := range file.Authors {
+		if !authorIDPattern.MatchString(id) {
+			log.Printf("作者 ID %s 只能包含字母、数字、- 和 _，已跳过", id)
+			continue
+		}
+		if author == nil {
+			author = &AuthorProfile{}
+		}
+		author.ID = id
+		if author.Name == "" {
+			author.Name = id
+		}
+		authors[id] = author
+	}
+
+	return authors, nil
+}
+
+// resolvePostAuthors 根据文章头部的 author 字段填充作者信息
+func resolvePostAuthors(posts []PostMetadata, authors map[string]*AuthorProfile) {
+	for i := range posts {
+		id := posts[i].Author
+		if id == "" {
+			continue
+		}
+		if !authorIDPattern.MatchString(id) {
+			log.Printf("文章 %s 的作者 ID %s 只能包含字母、数字、- 和 _，已忽略", posts[i].URI, id)
+			continue
+		}
+
+		author, exists := authors[id]
+		if !exists {
+			log.Printf("文章 %s 的作者 %s 未在作者文件中定义", posts[i].URI, id)
+			author = &AuthorProfile{ID: id, Name: id}
+			authors[id] = author
+		}
+		posts[i].AuthorInfo = author
+	}
+}
+
+// hasAuthorTemplate 判断主题中是否有作者页面模板，旧版主题没有 authors.html
+func hasAuthorTemplate(templateDir string) bool {
+	_, err := os.Stat(filepath.Join(templateDir, "authors.html"))
+	return err == nil
+}
+
+// GenerateAuthorPages 为每位作者生成 /authors/<id>/ 页面，列出该作者的文章。
+// 主题中没有 authors.html 或没有文章设置作者时跳过
+func GenerateAuthorPages(posts []PostMetadata, authors map[string]*AuthorProfile, blogConfig *BlogConfig, templateDir, outputDir string) error {
+	if !hasAuthorTemplate(templateDir) {
+		log.Printf("主题中没有 authors.html，跳过作者页面")
+		return nil
+	}
+	hasAuthor := false
+	for _, post := range posts {
+		if post.AuthorInfo != nil {
+			hasAuthor = true
+			break
+		}
+	}
+	if !hasAuthor {
+		log.Printf("没有文章设置作者，跳过作者页面")
+		return nil
+	}
+
+	funcMap := template.FuncMap{
+		"safeHTML": safeHTML,
+		"add":      func(x, y int) int { return x + y },
+		"sub":      func(x, y int) int { return x - y },
+	}
+
+	tmpl, err := template.New("").Funcs(funcMap).ParseFiles(
+		filepath.Join(templateDir, "header.html"),
+		filepath.Join(templateDir, "authors.html"),
+		filepath.Join(templateDir, "footer.html"),
+	)
+	if err != nil {
+		return fmt.Errorf("解析作者模板失败: %v", err)
+	}
+
+	for id, author := range authors {
+		var authorPosts []PostMetadata
+		for _, post := range posts {
+			if post.AuthorInfo != nil && post.AuthorInfo.ID == id {
+				authorPosts = append(authorPosts, post)
+			}
+		}
+
+		// 对作者的文章按日期排序
+		sort.Slice(authorPosts, func(i, j int) bool {
+			return authorPosts[i].Date > authorPosts[j].Date
+		})
+
+		// 分页处理，没有文章的作者也生成一页简介
+		totalPages := (len(authorPosts) + postsPerPage - 1) / postsPerPage
+		if totalPages == 0 {
+			totalPages = 1
+		}
+		for pageIndex := 0; pageIndex < totalPages; pageIndex++ {
+			startIndex := pageIndex * postsPerPage
+			endIndex := startIndex + postsPerPage
+			if endIndex > len(authorPosts) {
+				endIndex = len(authorPosts)
+			}
+
+			pagePosts := authorPosts[startIndex:endIndex]
+			outputPath := filepath.Join(outputDir, "authors", id, "index.html")
+			if pageIndex > 0 {
+				outputPath = filepath.Join(outputDir, "authors", id, fmt.Sprintf("page/%d", pageIndex+1), "index.html")
+			}
+			os.MkdirAll(filepath.Dir(outputPath), os.ModePerm)
+
+			f, err := os.Create(outputPath)
+			if err != nil {
+				return fmt.Errorf("创建文件失败 %s: %v", outputPath, err)
+			}
+
+			BlogData := map[string]interface{}{
+				"BlogTitle":       blogConfig.Title,
+				"BlogDescription": blogConfig.Description,
+				"BlogURI":         blogConfig.URI,
+				"BlogTags":        blogConfig.Tags,
+				"BlogAuthor":      blogConfig.Author,
+				"FeedLinks":       siteFeedLinks(blogConfig),
+				"Author":          author,
+				"Posts":           pagePosts,
+				"CurrentPage":     pageIndex + 1,
+				"TotalPages":      totalPages,
+				"SEO":             pageSEO(blogConfig, "作者: "+author.Name+" - "+blogConfig.Title, author.Bio, pageURL(sectionURL(blogConfig, "authors", id), pageIndex+1)),
+				"Robots":          pageRobots(blogConfig, "author", authorPosts),
+				"Mermaid":         summariesHaveMermaid(pagePosts),
+				"MermaidScript":   blogConfig.MermaidScript,
+				"PageType":        "author",
+			}
+
+			err = tmpl.ExecuteTemplate(f, "authors.html", BlogData)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("执行作者模板在页面 %s 失败  %d: %v", id, pageIndex+1, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,644 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	nethtml "golang.org/x/net/html"
+)
+
+// bibliographyDir 存放文章引用的 BibTeX 和 CSL-JSON 文件
+const bibliographyDir = "./data/bibliography"
+
+// 引用样式
+const (
+	citationNumeric    = "numeric"
+	citationAuthorYear = "author-year"
+)
+
+// BibEntry 是参考文献中的一个条目
+type BibEntry struct {
+	Key       string
+	Type      string
+	Authors   []BibName
+	Title     string
+	Container string // 期刊或会议名称
+	Publisher string
+	Year      string
+	Volume    string
+	Issue     string
+	Pages     string
+	URL       string
+	DOI       string
+}
+
+// BibName 是作者姓名
+type BibName struct {
+	Family string
+	Given  string
+}
+
+// citationPattern 匹配 [@key]、[@key, p. 12] 和 [@a; @b]
+var citationPattern = regexp.MustCompile(`\[(@[^\[\]]+)\]`)
+
+// citationKeyPattern 匹配引用中的条目键和可选的页码等说明
+var citationKeyPattern = regexp.MustCompile(`^@([\w:.#$%&+?<>~/-]+)\s*(?:,\s*(.*))?$`)
+
+// bibAuthorSeparator 分隔 BibTeX author 字段中的多个作者
+var bibAuthorSeparator = regexp.MustCompile(`\s+and\s+`)
+
+// loadBibliography 按扩展名读取 BibTeX 或 CSL-JSON 文件
+func loadBibliography(path string) (map[string]*BibEntry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*BibEntry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".bib":
+		entries, err = parseBibTeX(string(data))
+	case ".json":
+		entries, err = parseCSLJSON(data)
+	default:
+		return nil, fmt.Errorf("不支持的参考文献格式 %s，应为 .bib 或 .json", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*BibEntry)
+	for _, entry := range entries {
+		byKey[entry.Key] = entry
+	}
+	return byKey, nil
+}
+
+// parseBibTeX 解析 BibTeX 文件中的条目，忽略 @comment、@preamble 和 @string。
+// 条目之间不是 @类型{ 的文字按 BibTeX 的约定视为注释，其中的 @ 也不作为条目开始
+func parseBibTeX(data string) ([]*BibEntry, error) {
+	p := &bibParser{src: data}
+
+	var entries []*BibEntry
+	for {
+		at := strings.IndexByte(p.src[p.pos:], '@')
+		if at < 0 {
+			return entries, nil
+		}
+		p.pos += at + 1
+
+		entryType := strings.ToLower(p.readName())
+		p.skipSpaces()
+		if entryType == "" || p.eof() || (p.peek() != '{' && p.peek() != '(') {
+			continue
+		}
+		if entryType == "comment" || entryType == "preamble" || entryType == "string" {
+			if _, err := p.readBraced(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		p.pos++
+
+		p.skipSpaces()
+		key := strings.TrimSpace(p.readUntil(",}"))
+		if key == "" {
+			return nil, p.errorf("@%s 条目缺少键", entryType)
+		}
+
+		fields, err := p.readFields()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		entries = append(entries, bibTeXEntry(key, entryType, fields))
+	}
+}
+
+type bibParser struct {
+	src string
+	pos int
+}
+
+func (p *bibParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *bibParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *bibParser) skipSpaces() {
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.peek()) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *bibParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("第 %d 行: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *bibParser) readName() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if !(c == '_' || c == '-' || c == ':' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))) {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *bibParser) readUntil(stops string) string {
+	start := p.pos
+	for !p.eof() && strings.IndexByte(stops, p.peek()) < 0 {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// readBraced 读取配对的 {...} 或 (...)，返回其中的内容
+func (p *bibParser) readBraced() (string, error) {
+	open := p.peek()
+	closing := byte('}')
+	if open == '(' {
+		closing = ')'
+	}
+	p.pos++
+	start, depth := p.pos, 0
+	for ; !p.eof(); p.pos++ {
+		switch c := p.peek(); {
+		case c == open:
+			depth++
+		case c == closing && depth == 0:
+			p.pos++
+			return p.src[start : p.pos-1], nil
+		case c == closing:
+			depth--
+		}
+	}
+	return "", p.errorf("缺少配对的 %c", closing)
+}
+
+// readFields 读取条目中的 name = value 字段，直到条目结束
+func (p *bibParser) readFields() (map[string]string, error) {
+	fields := make(map[string]string)
+	for {
+		p.skipSpaces()
+		for !p.eof() && p.peek() == ',' {
+			p.pos++
+			p.skipSpaces()
+		}
+		if p.eof() {
+			return nil, p.errorf("条目缺少结尾的 }")
+		}
+		if c := p.peek(); c == '}' || c == ')' {
+			p.pos++
+			return fields, nil
+		}
+
+		name := strings.ToLower(p.readName())
+		p.skipSpaces()
+		if name == "" || p.eof() || p.peek() != '=' {
+			return nil, p.errorf("字段格式应为 name = value")
+		}
+		p.pos++
+
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+}
+
+// readValue 读取由 # 连接的 {...}、"..." 或数字
+func (p *bibParser) readValue() (string, error) {
+	var parts []string
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return "", p.errorf("字段缺少值")
+		}
+
+		switch p.peek() {
+		case '{':
+			part, err := p.readBraced()
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		case '"':
+			p.pos++
+			start, depth := p.pos, 0
+			for ; !p.eof() && (p.peek() != '"' || depth > 0); p.pos++ {
+				switch p.peek() {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+			}
+			if p.eof() {
+				return "", p.errorf("缺少配对的引号")
+			}
+			parts = append(parts, p.src[start:p.pos])
+			p.pos++
+		default:
+			part := strings.TrimSpace(p.readUntil(",}#\n"))
+			if part == "" {
+				return "", p.errorf("字段缺少值")
+			}
+			parts = append(parts, part)
+		}
+
+		p.skipSpaces()
+		if p.eof() || p.peek() != '#' {
+			return cleanBibValue(strings.Join(parts, "")), nil
+		}
+		p.pos++
+	}
+}
+
+// cleanBibValue 去掉 BibTeX 值中的花括号和常见的转义
+func cleanBibValue(value string) string {
+	value = strings.NewReplacer(`\&`, "&", `\%`, "%", `\$`, "$", `\_`, "_", "---", "—", "--", "–", "{", "", "}", "").Replace(value)
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// bibTeXEntry 将 BibTeX 字段转换为条目
+func bibTeXEntry(key, entryType string, fields map[string]string) *BibEntry {
+	entry := &BibEntry{
+		Key:       key,
+		Type:      entryType,
+		Title:     fields["title"],
+		Publisher: fields["publisher"],
+		Year:      fields["year"],
+		Volume:    fields["volume"],
+		Issue:     fields["number"],
+		Pages:     fields["pages"],
+		URL:       fields["url"],
+		DOI:       fields["doi"],
+	}
+	for _, name := range []string{"journal", "booktitle", "school", "institution"} {
+		if fields[name] != "" {
+			entry.Container = fields[name]
+			break
+		}
+	}
+	if entry.Publisher == "" {
+		entry.Publisher = fields["organization"]
+	}
+	if entry.Year == "" && len(fields["date"]) >= 4 {
+		entry.Year = fields["date"][:4]
+	}
+
+	authors := fields["author"]
+	if authors == "" {
+		authors = fields["editor"]
+	}
+	for _, name := range bibAuthorSeparator.Split(authors, -1) {
+		if name = strings.TrimSpace(name); name != "" {
+			entry.Authors = append(entry.Authors, parseBibName(name))
+		}
+	}
+	return entry
+}
+
+// parseBibName 解析 "姓, 名" 或 "名 姓" 格式的姓名，没有空格的姓名整体作为姓
+func parseBibName(name string) BibName {
+	if i := strings.Index(name, ","); i >= 0 {
+		return BibName{Family: strings.TrimSpace(name[:i]), Given: strings.TrimSpace(name[i+1:])}
+	}
+	if i := strings.LastIndex(name, " "); i >= 0 {
+		return BibName{Family: name[i+1:], Given: name[:i]}
+	}
+	return BibName{Family: name}
+}
+
+// cslValue 兼容 CSL-JSON 中以字符串或数字表示的字段
+type cslValue string
+
+func (v *cslValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = cslValue(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*v = cslValue(n.String())
+	return nil
+}
+
+type cslName struct {
+	Family  string `json:"family"`
+	Given   string `json:"given"`
+	Literal string `json:"literal"`
+}
+
+type cslItem struct {
+	ID             cslValue  `json:"id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title"`
+	Author         []cslName `json:"author"`
+	Editor         []cslName `json:"editor"`
+	ContainerTitle string    `json:"container-title"`
+	Publisher      string    `json:"publisher"`
+	Volume         cslValue  `json:"volume"`
+	Issue          cslValue  `json:"issue"`
+	Page           cslValue  `json:"page"`
+	URL            string    `json:"URL"`
+	DOI            string    `json:"DOI"`
+	Issued         struct {
+		DateParts [][]cslValue `json:"date-parts"`
+		Literal   string       `json:"literal"`
+	} `json:"issued"`
+}
+
+// parseCSLJSON 解析 CSL-JSON 格式的条目数组
+func parseCSLJSON(data []byte) ([]*BibEntry, error) {
+	var items []cslItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("解析 CSL-JSON 失败: %v", err)
+	}
+
+	var entries []*BibEntry
+	for i, item := range items {
+		if item.ID == "" {
+			return nil, fmt.Errorf("第 %d 个条目缺少 id", i+1)
+		}
+		entry := &BibEntry{
+			Key:       string(item.ID),
+			Type:      item.Type,
+			Title:     item.Title,
+			Container: item.ContainerTitle,
+			Publisher: item.Publisher,
+			Year:      item.Issued.Literal,
+			Volume:    string(item.Volume),
+			Issue:     string(item.Issue),
+			Pages:     string(item.Page),
+			URL:       item.URL,
+			DOI:       item.DOI,
+		}
+		if len(item.Issued.DateParts) > 0 && len(item.Issued.DateParts[0]) > 0 {
+			entry.Year = string(item.Issued.DateParts[0][0])
+		}
+
+		names := item.Author
+		if len(names) == 0 {
+			names = item.Editor
+		}
+		for _, name := range names {
+			if name.Literal != "" {
+				entry.Authors = append(entry.Authors, BibName{Family: name.Literal})
+				continue
+			}
+			entry.Authors = append(entry.Authors, BibName{Family: name.Family, Given: name.Given})
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// citation 是一次引用中的一个条目
+type citation struct {
+	key     string
+	locator string // 页码等说明
+}
+
+// parseCitation 解析方括号中的引用，不符合引用格式时返回 false
+func parseCitation(text string) ([]citation, bool) {
+	var citations []citation
+	for _, part := range strings.Split(text, ";") {
+		match := citationKeyPattern.FindStringSubmatch(strings.TrimSpace(part))
+		if match == nil {
+			return nil, false
+		}
+		citations = append(citations, citation{key: match[1], locator: strings.TrimSpace(match[2])})
+	}
+	return citations, true
+}
+
+// processCitations 将正文中的 [@key] 替换为编号或作者-年份格式的引用，并在文末添加参考文献列表
+func processCitations(doc *goquery.Document, post *PostMetadata, config *BlogConfig, state *renderState) {
+	if post.Bibliography == "" {
+		return
+	}
+
+	name, ok := relativeName(post.Bibliography)
+	if !ok {
+		state.diagnostics = append(state.diagnostics, Diagnostic{post.FileName, levelError, fmt.Sprintf("参考文献 %s 不在 bibliography 目录中", post.Bibliography)})
+		return
+	}
+	entries, err := loadBibliography(filepath.Join(bibliographyDir, filepath.FromSlash(name)))
+	if err != nil {
+		state.diagnostics = append(state.diagnostics, Diagnostic{post.FileName, levelError, fmt.Sprintf("读取参考文献 %s 失败: %v", post.Bibliography, err)})
+		return
+	}
+
+	style := post.CitationStyle
+	if style == "" {
+		style = config.CitationStyle
+	}
+	if style != citationNumeric && style != citationAuthorYear {
+		state.diagnostics = append(state.diagnostics, Diagnostic{post.FileName, levelWarning, fmt.Sprintf("不支持的引用样式 %s，使用 %s", style, citationNumeric)})
+		style = citationNumeric
+	}
+
+	body := doc.Find("body")
+	if body.Length() == 0 {
+		return
+	}
+
+	// 先收集文本节点，再逐个替换
+	var textNodes []*nethtml.Node
+	collectCitationText(body.Nodes[0], &textNodes)
+
+	numbers := make(map[string]int)
+	var cited []*BibEntry
+	for _, node := range textNodes {
+		if !citationPattern.MatchString(node.Data) {
+			continue
+		}
+
+		replaced := false
+		var builder strings.Builder
+		last := 0
+		for _, loc := range citationPattern.FindAllStringSubmatchIndex(node.Data, -1) {
+			citations, ok := parseCitation(node.Data[loc[2]:loc[3]])
+			if !ok {
+				continue
+			}
+			replaced = true
+
+			var items []string
+			for _, c := range citations {
+				entry, exists := entries[c.key]
+				if !exists {
+					state.diagnostics = append(state.diagnostics, Diagnostic{post.FileName, levelWarning, fmt.Sprintf("参考文献 %s 中没有条目 @%s", post.Bibliography, c.key)})
+					items = append(items, `<span class="citation-missing">@`+html.EscapeString(c.key)+`</span>`)
+					continue
+				}
+				if numbers[c.key] == 0 {
+					cited = append(cited, entry)
+					numbers[c.key] = len(cited)
+				}
+				items = append(items, citationItem(entry, numbers[c.key], c.locator, style))
+			}
+
+			builder.WriteString(html.EscapeString(node.Data[last:loc[0]]))
+			if style == citationNumeric {
+				builder.WriteString(`<span class="citation">[` + strings.Join(items, ", ") + `]</span>`)
+			} else {
+				builder.WriteString(`<span class="citation">(` + strings.Join(items, "; ") + `)</span>`)
+			}
+			last = loc[1]
+		}
+		if !replaced {
+			continue
+		}
+		builder.WriteString(html.EscapeString(node.Data[last:]))
+
+		replacement, err := nethtml.ParseFragment(strings.NewReader(builder.String()), node.Parent)
+		if err != nil {
+			continue
+		}
+		for _, n := range replacement {
+			node.Parent.InsertBefore(n, node)
+		}
+		node.Parent.RemoveChild(node)
+	}
+
+	if len(cited) > 0 {
+		body.AppendHtml(bibliographyHTML(cited, style, config.BibliographyTitle))
+	}
+}
+
+// collectCitationText 按文档顺序收集可能包含引用的文本节点，跳过代码和链接
+func collectCitationText(n *nethtml.Node, nodes *[]*nethtml.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case nethtml.TextNode:
+			*nodes = append(*nodes, c)
+		case nethtml.ElementNode:
+			switch c.Data {
+			case "code", "pre", "a", "script", "style":
+				continue
+			}
+			collectCitationText(c, nodes)
+		}
+	}
+}
+
+// citationItem 返回一个条目在正文中的引用文字
+func citationItem(entry *BibEntry, number int, locator, style string) string {
+	text := fmt.Sprint(number)
+	if style == citationAuthorYear {
+		text = citationLabel(entry) + ", " + citationYear(entry)
+	}
+	if locator != "" {
+		text += ", " + locator
+	}
+	return `<a href="#ref-` + html.EscapeString(entry.Key) + `">` + html.EscapeString(text) + `</a>`
+}
+
+// citationLabel 返回作者-年份格式中的作者部分
+func citationLabel(entry *BibEntry) string {
+	switch len(entry.Authors) {
+	case 0:
+		return entry.Title
+	case 1:
+		return entry.Authors[0].Family
+	case 2:
+		return entry.Authors[0].Family + " & " + entry.Authors[1].Family
+	}
+	return entry.Authors[0].Family + " et al."
+}
+
+func citationYear(entry *BibEntry) string {
+	if entry.Year == "" {
+		return "n.d."
+	}
+	return entry.Year
+}
+
+// bibliographyHTML 生成参考文献列表，编号样式按引用顺序排列，作者-年份样式按作者和年份排序
+func bibliographyHTML(cited []*BibEntry, style, title string) string {
+	tag := "ol"
+	if style == citationAuthorYear {
+		tag = "ul"
+		cited = append([]*BibEntry(nil), cited...)
+		sort.SliceStable(cited, func(i, j int) bool {
+			a, b := citationLabel(cited[i]), citationLabel(cited[j])
+			if a != b {
+				return strings.ToLower(a) < strings.ToLower(b)
+			}
+			return cited[i].Year < cited[j].Year
+		})
+	}
+
+	var builder strings.Builder
+	builder.WriteString(`<section class="bibliography"><h2>` + html.EscapeString(title) + `</h2><` + tag + `>`)
+	for _, entry := range cited {
+		builder.WriteString(`<li id="ref-` + html.EscapeString(entry.Key) + `">` + formatBibEntry(entry) + `</li>`)
+	}
+	builder.WriteString(`</` + tag + `></section>`)
+	return builder.String()
+}
+
+// formatBibEntry 将条目格式化为 "作者 (年份). 标题. 期刊, 卷(期), 页码. 出版者. 链接"
+func formatBibEntry(entry *BibEntry) string {
+	var names []string
+	for _, name := range entry.Authors {
+		names = append(names, strings.TrimSpace(name.Given+" "+name.Family))
+	}
+
+	var parts []string
+	if len(names) > 0 {
+		parts = append(parts, html.EscapeString(strings.Join(names, ", "))+" ("+html.EscapeString(citationYear(entry))+")")
+	} else {
+		parts = append(parts, "("+html.EscapeString(citationYear(entry))+")")
+	}
+	if entry.Title != "" {
+		parts = append(parts, html.EscapeString(entry.Title))
+	}
+
+	if entry.Container != "" {
+		container := "<em>" + html.EscapeString(entry.Container) + "</em>"
+		if entry.Volume != "" {
+			container += ", " + html.EscapeString(entry.Volume)
+			if entry.Issue != "" {
+				container += "(" + html.EscapeString(entry.Issue) + ")"
+			}
+		}
+		if entry.Pages != "" {
+			container += ", " + html.EscapeString(entry.Pages)
+		}
+		parts = append(parts, container)
+	}
+	if entry.Publisher != "" {
+		parts = append(parts, html.EscapeString(entry.Publisher))
+	}
+
+	text := strings.Join(parts, ". ") + "."
+	link := entry.URL
+	if entry.DOI != "" {
+		link = "https://doi.org/" + strings.TrimPrefix(entry.DOI, "https://doi.org/")
+	}
+	if link != "" && isSafeURL(link) {
+		text += ` <a href="` + html.EscapeString(link) + `">` + html.EscapeString(link) + `</a>`
+	}
+	return text
+}
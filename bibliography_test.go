@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBibTeX(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []*BibEntry
+		err   string // 期望的错误信息，为空时不应出错
+	}{
+		{
+			name: "期刊文章",
+			input: `@article{knuth1984,
+  author  = {Knuth, Donald E.},
+  title   = {Literate {P}rogramming},
+  journal = "The Computer Journal",
+  year    = 1984,
+  volume  = {27},
+  number  = {2},
+  pages   = {97--111}
+}`,
+			want: []*BibEntry{{
+				Key: "knuth1984", Type: "article", Authors: []BibName{{Family: "Knuth", Given: "Donald E."}},
+				Title: "Literate Programming", Container: "The Computer Journal", Year: "1984",
+				Volume: "27", Issue: "2", Pages: "97–111",
+			}},
+		},
+		{
+			name:  "多个作者和字符串连接",
+			input: `@book{k, author = {Brian W. Kernighan and Dennis Ritchie}, title = "The C " # {Programming Language}, publisher = {Prentice Hall}, date = {1978-02-22}}`,
+			want: []*BibEntry{{
+				Key: "k", Type: "book", Authors: []BibName{{Family: "Kernighan", Given: "Brian W."}, {Family: "Ritchie", Given: "Dennis"}},
+				Title: "The C Programming Language", Publisher: "Prentice Hall", Year: "1978",
+			}},
+		},
+		{
+			name: "条目之间的注释",
+			input: `这是注释，联系 someone@example.com
+@comment{忽略 @misc{x}}
+% 见 @misc 条目
+@string{acm = "ACM"}
+@misc(m1, title = {A \& B})`,
+			want: []*BibEntry{{Key: "m1", Type: "misc", Title: "A & B"}},
+		},
+		{name: "缺少键", input: `@article{, title = {T}}`, err: "缺少键"},
+		{name: "缺少结尾", input: `@article{k, title = {T}`, err: "缺少结尾的 }"},
+		{name: "字段格式错误", input: "@article{k,\n title {T}}", err: "第 2 行"},
+	}
+
+	for _, tt := range tests {
+		got, err := parseBibTeX(tt.input)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: 错误为 %v，期望包含 %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: 出错: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: 得到 %s，期望 %s", tt.name, formatEntries(got), formatEntries(tt.want))
+		}
+	}
+}
+
+func TestParseCSLJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []*BibEntry
+		err   string
+	}{
+		{
+			name: "日期和数字字段",
+			input: `[{"id": "doe2020", "type": "article-journal", "title": "Title",
+				"author": [{"family": "Doe", "given": "Jane"}, {"literal": "W3C"}],
+				"container-title": "Journal", "volume": 3, "page": "1-10",
+				"issued": {"date-parts": [[2020, 5]]}, "DOI": "10.1/x"}]`,
+			want: []*BibEntry{{
+				Key: "doe2020", Type: "article-journal", Title: "Title",
+				Authors:   []BibName{{Family: "Doe", Given: "Jane"}, {Family: "W3C"}},
+				Container: "Journal", Volume: "3", Pages: "1-10", Year: "2020", DOI: "10.1/x",
+			}},
+		},
+		{
+			name:  "没有作者时使用编者",
+			input: `[{"id": 7, "title": "T", "editor": [{"family": "Roe"}], "issued": {"literal": "2001"}}]`,
+			want:  []*BibEntry{{Key: "7", Title: "T", Authors: []BibName{{Family: "Roe"}}, Year: "2001"}},
+		},
+		{name: "缺少 id", input: `[{"title": "T"}]`, err: "缺少 id"},
+		{name: "格式错误", input: `{"id": "x"}`, err: "解析 CSL-JSON 失败"},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCSLJSON([]byte(tt.input))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: 错误为 %v，期望包含 %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: 出错: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: 得到 %s，期望 %s", tt.name, formatEntries(got), formatEntries(tt.want))
+		}
+	}
+}
+
+func TestParseCitation(t *testing.T) {
+	tests := []struct {
+		text string
+		want []citation
+		ok   bool
+	}{
+		{text: "@knuth1984", want: []citation{{key: "knuth1984"}}, ok: true},
+		{text: "@knuth1984, p. 12", want: []citation{{key: "knuth1984", locator: "p. 12"}}, ok: true},
+		{text: "@a; @b", want: []citation{{key: "a"}, {key: "b"}}, ok: true},
+		{text: "@a; 不是引用", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseCitation(tt.text)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseCitation(%q) = %v, %v，期望 %v, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// formatEntries 将条目格式化为便于比较的文字
+func formatEntries(entries []*BibEntry) string {
+	var parts []string
+	for _, entry := range entries {
+		parts = append(parts, fmt.Sprintf("%+v", *entry))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -94,6 +94,13 @@ func GenerateCategoryPages(posts []PostMetadata, blogConfig *BlogConfig, templat
 			return allCategorizedPosts[i].Date > allCategorizedPosts[j].Date
 		})
 
+		// 生成分类订阅
+		if err := generateSectionFeed(allCategorizedPosts, blogConfig, "categories", category, "分类: "+category, outputDir); err != nil {
+			log.Printf("生成分类 %s 的订阅失败: %v", category, err)
+		}
+		feedBase := sectionURL(blogConfig, "categories", category) + "feed/"
+		links := append(feedLinks(blogConfig, feedBase, blogConfig.Title+" - 分类: "+category), siteFeedLinks(blogConfig)...)
+
 		// 分页处理
 		totalPages := (len(allCategorizedPosts) + postsPerPage - 1) / postsPerPage
 		for pageIndex := 0; pageIndex < totalPages; pageIndex++ {
@@ -121,10 +128,16 @@ func GenerateCategoryPages(posts []PostMetadata, blogConfig *BlogConfig, templat
 				"BlogDescription": blogConfig.Description,
 				"BlogURI":         blogConfig.URI,
 				"BlogAuthor":      blogConfig.Author,
+				"FeedLinks":       links,
+				"FeedURL":         primaryFeedURL(blogConfig, feedBase),
 				"Category":        category,
 				"Posts":           pagePosts,
 				"CurrentPage":     pageIndex + 1,
 				"TotalPages":      totalPages,
+				"SEO":             pageSEO(blogConfig, "分类: "+category+" - "+blogConfig.Title, "所有 "+blogConfig.Title+" 中分类为 "+category+" 的文章", pageURL(sectionURL(blogConfig, "categories", category), pageIndex+1)),
+				"Robots":          pageRobots(blogConfig, "category", allCategorizedPosts),
+				"Mermaid":         summariesHaveMermaid(pagePosts),
+				"MermaidScript":   blogConfig.MermaidScript,
 				"PageType":        "category",
 			}
 
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"gopkg.in/yaml.v2"
+)
+
+// Diagnostic 记录一条内容检查或生成过程中发现的问题
+type Diagnostic struct {
+	File    string
+	Level   string // error 或 warning
+	Message string
+}
+
+const (
+	levelError   = "error"
+	levelWarning = "warning"
+)
+
+// 站点中由生成器输出的目录，指向这些目录的链接不按文章 URI 检查
+var generatedSections = []string{"tags", "categories", "authors", "page", "feed", "search", "res", "sitemap.xml", "robots.txt"}
+
+// runContentCheck 读取配置和全部文章并执行内容检查，同时返回每个片段被哪些文章使用
+func runContentCheck() ([]Diagnostic, map[string][]string, error) {
+	config, err := LoadBlogConfig("./data/.env")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	diagnostics, err := checkPostFiles("./data/posts")
+	if err != nil {
+		return nil, nil, err
+	}
+	diagnostics = append(config.ConfigProblems, diagnostics...)
+
+	posts, err := ReadPostMetadata("./data/posts", config)
+	if err != nil {
+		return nil, nil, err
+	}
+	state := renderPosts(posts, config, true)
+	diagnostics = append(diagnostics, state.diagnostics...)
+
+	diagnostics = append(diagnostics, checkPosts(posts, config)...)
+	return diagnostics, snippetUsers(posts), nil
+}
+
+// hasErrors 判断检查结果中是否存在错误级别的问题
+func hasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Level == levelError {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPostFiles 检查文章文件能否被解析，无法解析的文件在生成时会被直接跳过
+func checkPostFiles(postPath string) ([]Diagnostic, error) {
+	var diagnostics []Diagnostic
+
+	files, err := ioutil.ReadDir(postPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if filepath.Ext(file.Name()) != ".md" {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(postPath, file.Name()))
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{file.Name(), levelError, fmt.Sprintf("无法读取文件: %v", err)})
+			continue
+		}
+
+		sections := strings.SplitN(string(content), "---", 3)
+		if len(sections) < 3 {
+			diagnostics = append(diagnostics, Diagnostic{file.Name(), levelError, "缺少由 --- 包围的头部信息"})
+			continue
+		}
+
+		var metadata PostMetadata
+		if err := yaml.Unmarshal([]byte(sections[1]), &metadata); err != nil {
+			diagnostics = append(diagnostics, Diagnostic{file.Name(), levelError, fmt.Sprintf("头部信息 YAML 解析失败: %v", err)})
+		}
+	}
+
+	return diagnostics, nil
+}
+
+// checkPosts 检查重复的 URI、缺失的必填字段、无效日期、失效的站内链接和缺失的图片
+func checkPosts(posts []PostMetadata, config *BlogConfig) []Diagnostic {
+	var diagnostics []Diagnostic
+
+	// 记录所有文章 URI 和别名，用于检测重复及校验站内链接
+	owners := make(map[string]string)
+	for _, post := range posts {
+		uri := strings.Trim(post.URI, "/")
+		if uri == "" {
+			continue
+		}
+		if owner, exists := owners[uri]; exists {
+			diagnostics = append(diagnostics, Diagnostic{post.FileName, levelError, fmt.Sprintf("URI %s 与 %s 重复，生成时会互相覆盖", uri, owner)})
+			continue
+		}
+		owners[uri] = post.FileName
+	}
+	for _, post := range posts {
+		for _, alias := range post.Aliases {
+			alias, ok := cleanAlias(alias)
+			if !ok {
+				diagnostics = append(diagnostics, Diagnostic{post.FileName, levelError, fmt.Sprintf("别名 %s 不是有效的站内路径", alias)})
+				continue
+			}
+			if owner, exists := owners[alias]; exists && owner != post.FileName {
+				diagnostics = append(diagnostics, Diagnostic{post.FileName, levelError, fmt.Sprintf("别名 %s 与 %s 的 URI 或别名重复", alias, owner)})
+				continue
+			}
+			owners[alias] = post.FileName
+		}
+	}
+
+	for _, post := range posts {
+		if post.Title == "" {
+			diagnostics = append(diagnostics, Diagnostic{post.FileName, levelError, "缺少 title"})
+		}
+		if post.URI == "" {
+			diagnostics = append(diagnostics, Diagnostic{post.FileName, levelError, "缺少 uri"})
+		}
+		if post.Author != "" && !authorIDPattern.MatchString(post.Author) {
+			diagnostics = append(diagnostics, Diagnostic{post.FileName, levelError, fmt.Sprintf("作者 ID %s 只能包含字母、数字、- 和 _", post.Author)})
+		}
+		if post.Date == "" {
+			diagnostics = append(diagnostics, Diagnostic{post.FileName, levelError, "缺少 date"})
+		} else if _, err := time.Parse("2006-01-02", post.Date); err != nil {
+			diagnostics = append(diagnostics, Diagnostic{post.FileName, levelError, fmt.Sprintf("date %s 不是有效的 YYYY-MM-DD 日期", post.Date)})
+		}
+
+		diagnostics = append(diagnostics, checkPostLinks(post, owners, config)...)
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].File < diagnostics[j].File
+	})
+	return diagnostics
+}
+
+// checkPostLinks 检查正文中的站内链接和本地图片
+func checkPostLinks(post PostMetadata, owners map[string]string, config *BlogConfig) []Diagnostic {
+	var diagnostics []Diagnostic
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(post.ContentHTML))
+	if err != nil {
+		return diagnostics
+	}
+
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		path, ok := sitePath(href, config)
+		if !ok || path == "" {
+			return
+		}
+		if owners[path] != "" || isGeneratedPath(path) || localAssetExists(path) {
+			return
+		}
+		diagnostics = append(diagnostics, Diagnostic{post.FileName, levelError, fmt.Sprintf("站内链接 %s 指向不存在的地址", href)})
+	})
+
+	doc.Find("img[src]").Each(func(i int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		path, ok := sitePath(src, config)
+		if !ok || localAssetExists(path) {
+			return
+		}
+		diagnostics = append(diagnostics, Diagnostic{post.FileName, levelError, fmt.Sprintf("图片 %s 不存在", src)})
+	})
+
+	return diagnostics
+}
+
+// sitePath 将站内地址转换为相对站点根目录的路径，站外地址返回 false
+func sitePath(href string, config *BlogConfig) (string, bool) {
+	if href == "" || strings.HasPrefix(href, "#") {
+		return "", false
+	}
+
+	if strings.HasPrefix(href, config.URI+"/") || href == config.URI {
+		href = strings.TrimPrefix(href, config.URI)
+	} else {
+		u, err := url.Parse(href)
+		if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+			return "", false
+		}
+
+		// 站点部署在子路径时，去掉子路径前缀
+		if base, err := url.Parse(config.URI); err == nil {
+			basePath := strings.TrimSuffix(base.Path, "/")
+			if basePath != "" && !strings.HasPrefix(u.Path, basePath+"/") {
+				return "", false
+			}
+			href = strings.TrimPrefix(u.Path, basePath)
+		}
+	}
+
+	if i := strings.IndexAny(href, "?#"); i >= 0 {
+		href = href[:i]
+	}
+	path, err := url.PathUnescape(href)
+	if err != nil {
+		path = href
+	}
+	return strings.Trim(path, "/"), true
+}
+
+// isGeneratedPath 判断路径是否属于生成器输出的目录或文件
+func isGeneratedPath(path string) bool {
+	for _, section := range generatedSections {
+		if path == section || strings.HasPrefix(path, section+"/") {
+			return true
+		}
+	}
+	// 地址过多时拆分出的 sitemap-N.xml
+	return strings.HasPrefix(path, "sitemap-") && strings.HasSuffix(path, ".xml")
+}
+
+// localAssetExists 判断站内文件是否存在，res/ 下的文件来自主题，其余来自 static 目录
+func localAssetExists(path string) bool {
+	_, err := os.Stat(localAssetPath(path))
+	return err == nil
+}
+
+// localAssetPath 返回站内文件对应的源文件路径
+func localAssetPath(path string) string {
+	if strings.HasPrefix(path, "res/") {
+		return filepath.Join("./data/templates", filepath.FromSlash(path))
+	}
+	return filepath.Join("./data/static", filepath.FromSlash(path))
+}
+
+// checkCommand 在命令行输出检查结果并返回退出状态码
+func checkCommand() int {
+	diagnostics, users, err := runContentCheck()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "检查失败: %v\n", err)
+		return 2
+	}
+
+	for _, d := range diagnostics {
+		fmt.Printf("%s: %s: %s\n", d.File, d.Level, d.Message)
+	}
+	// 列出片段被哪些文章使用，修改片段后这些文章会随之重新生成
+	for _, file := range sortedKeys(users) {
+		fmt.Printf("片段 %s 被 %s 使用\n", file, strings.Join(users[file], ", "))
+	}
+	if hasErrors(diagnostics) {
+		return 1
+	}
+	fmt.Println("检查通过。")
+	return 0
+}
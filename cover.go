@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// mediaExtensions 是媒体库中列出的图片格式
+var mediaExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".svg"}
+
+// resolveCover 为文章头部 cover 字段指定的封面生成缩略图和通栏图片，返回发现的问题。
+// 站外图片和无法缩放的格式直接使用原图
+func resolveCover(post *PostMetadata, config *BlogConfig, state *renderState) []Diagnostic {
+	if post.Cover == "" {
+		return nil
+	}
+
+	cover := strings.TrimPrefix(post.Cover, config.URI)
+	if u, err := url.Parse(cover); err == nil && u.IsAbs() {
+		post.CoverThumbnail = post.Cover
+		post.CoverLarge = post.Cover
+		return nil
+	}
+
+	assetPath := strings.TrimPrefix(path.Clean("/"+cover), "/")
+	sourcePath := localAssetPath(assetPath)
+	if _, err := os.Stat(sourcePath); err != nil {
+		return []Diagnostic{{post.FileName, levelError, fmt.Sprintf("封面图片 %s 不存在", post.Cover)}}
+	}
+
+	coverURL := config.URI + "/" + (&url.URL{Path: assetPath}).EscapedPath()
+	post.CoverThumbnail = coverURL
+	post.CoverLarge = coverURL
+
+	ext := strings.ToLower(path.Ext(assetPath))
+	if state.checkOnly || (ext != ".jpg" && ext != ".jpeg" && ext != ".png") {
+		return nil
+	}
+
+	// 原图比目标宽度窄时不会生成对应的图片，继续使用原图
+	_, _, variants, err := resizeImage(sourcePath, assetPath, []int{config.CoverThumbnailWidth, config.CoverWidth})
+	if err != nil {
+		return []Diagnostic{{post.FileName, levelWarning, fmt.Sprintf("处理封面图片 %s 失败: %v", post.Cover, err)}}
+	}
+	// 按宽度查找各个位置使用的图片，缩略图和通栏封面的宽度可以相同
+	variantURLs := make(map[int]string)
+	for _, variant := range variants {
+		state.imageVariants[variant.path] = variant.cache
+		variantURLs[variant.width] = config.URI + "/" + (&url.URL{Path: variant.path}).EscapedPath()
+	}
+	if variantURL, ok := variantURLs[config.CoverThumbnailWidth]; ok {
+		post.CoverThumbnail = variantURL
+	}
+	if variantURL, ok := variantURLs[config.CoverWidth]; ok {
+		post.CoverLarge = variantURL
+	}
+	return nil
+}
+
+// listMediaImages 返回 static 目录中的图片，路径相对站点根目录并以 / 开头，供后台选择封面
+func listMediaImages() ([]string, error) {
+	var images []string
+	err := filepath.Walk("./data/static", func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() || !containsString(mediaExtensions, strings.ToLower(filepath.Ext(filePath))) {
+			return nil
+		}
+		rel, err := filepath.Rel("./data/static", filePath)
+		if err != nil {
+			return err
+		}
+		images = append(images, "/"+filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(images)
+	return images, err
+}
+
+// readMediaFile 读取媒体库中的文件，不允许访问 static 目录之外的文件
+func readMediaFile(name string) ([]byte, error) {
+	cleaned := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if cleaned == "" {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.ReadFile(filepath.Join("./data/static", filepath.FromSlash(cleaned)))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// diagramCacheDir 存放本地命令生成的 SVG，图表源码和命令不变时跨次生成复用
+const diagramCacheDir = "./data/cache/diagrams"
+
+// diagramTimeout 是单个图表命令的最长运行时间
+const diagramTimeout = 30 * time.Second
+
+// diagramLanguages 为代码块语言到图表类型的对应关系
+var diagramLanguages = map[string]string{
+	"mermaid":  "mermaid",
+	"dot":      "dot",
+	"graphviz": "dot",
+}
+
+// processDiagrams 处理 mermaid 和 dot 代码块：配置了本地命令时在生成时转换为内联 SVG，
+// 否则 mermaid 图表输出为由浏览器渲染的容器，并标记文章需要加载 mermaid 脚本
+func processDiagrams(doc *goquery.Document, post *PostMetadata, config *BlogConfig, state *renderState) {
+	doc.Find("pre > code").Each(func(i int, s *goquery.Selection) {
+		class, _ := s.Attr("class")
+		kind, ok := diagramLanguages[strings.TrimPrefix(class, "language-")]
+		if !ok {
+			return
+		}
+		source := s.Text()
+		pre := s.Parent()
+
+		// 只有空白的命令视为未配置
+		if command := config.DiagramCommands[kind]; strings.TrimSpace(command) != "" {
+			// 内容检查时只确认命令存在，不执行命令
+			if state.checkOnly {
+				if _, err := exec.LookPath(strings.Fields(command)[0]); err != nil {
+					state.diagnostics = append(state.diagnostics, Diagnostic{post.FileName, levelWarning, fmt.Sprintf("找不到 %s 图表命令: %v", kind, err)})
+				}
+				return
+			}
+			svg, err := renderDiagram(kind, command, source)
+			if err != nil {
+				state.diagnostics = append(state.diagnostics, Diagnostic{post.FileName, levelWarning, fmt.Sprintf("渲染 %s 图表失败: %v", kind, err)})
+				return
+			}
+			pre.ReplaceWithHtml(`<figure class="diagram diagram-` + kind + `">` + svg + `</figure>`)
+			return
+		}
+
+		if kind == "mermaid" {
+			pre.ReplaceWithHtml(`<pre class="mermaid">` + html.EscapeString(source) + `</pre>`)
+			post.HasMermaid = true
+			return
+		}
+		state.diagnostics = append(state.diagnostics, Diagnostic{post.FileName, levelWarning, fmt.Sprintf("未配置 DIAGRAM_%s_COMMAND，%s 图表按代码块输出", strings.ToUpper(kind), kind)})
+	})
+}
+
+// renderDiagram 运行配置的命令将图表转换为 SVG，命令从标准输入读取源码并向标准输出写入 SVG
+func renderDiagram(kind, command, source string) (string, error) {
+	sum := sha256.Sum256([]byte(kind + "\x00" + command + "\x00" + source))
+	cachePath := filepath.Join(diagramCacheDir, hex.EncodeToString(sum[:16])+".svg")
+	if data, err := ioutil.ReadFile(cachePath); err == nil {
+		return string(data), nil
+	}
+
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", errors.New("未配置图表命令")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), diagramTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(source)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%v: %s", err, message)
+		}
+		return "", err
+	}
+
+	// 去掉 XML 声明和 DOCTYPE，只保留 <svg> 元素
+	svg := stdout.String()
+	start := strings.Index(svg, "<svg")
+	if start < 0 {
+		return "", errors.New("命令没有输出 SVG")
+	}
+	svg = strings.TrimSpace(svg[start:])
+
+	if err := os.MkdirAll(diagramCacheDir, os.ModePerm); err == nil {
+		if err := ioutil.WriteFile(cachePath, []byte(svg), 0644); err != nil {
+			log.Printf("写入图表缓存失败: %v", err)
+		}
+	}
+	return svg, nil
+}
+
+// summariesHaveMermaid 判断列表页面中是否有文章的摘要含有需要在浏览器中渲染的 mermaid 图表
+func summariesHaveMermaid(posts []PostMetadata) bool {
+	for _, post := range posts {
+		if strings.Contains(post.Summary, `<pre class="mermaid">`) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,50 +1,77 @@
 package main
 
 import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/url"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 type Feed struct {
-	ID        string
-	Title     string
-	Updated   string
-	Generator string
-	Author    []Author
-	Links     []Link
-	Subtitle  string
-	Logo      string
-	Rights    string
-	Entries   []Entry
+	XMLName   xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string   `xml:"id"`
+	Title     string   `xml:"title"`
+	Updated   string   `xml:"updated"`
+	Generator string   `xml:"generator"`
+	Author    []Author `xml:"author"`
+	Links     []Link   `xml:"link"`
+	Subtitle  string   `xml:"subtitle,omitempty"`
+	Logo      string   `xml:"logo,omitempty"`
+	Rights    string   `xml:"rights,omitempty"`
+	Entries   []Entry  `xml:"entry"`
+
+	Podcast *PodcastConfig `xml:"-"` // 启用播客时 RSS 订阅输出 iTunes 标签
 }
 
 type Author struct {
-	Name string
-	URI  string
+	Name  string `xml:"name"`
+	URI   string `xml:"uri,omitempty"`
+	Email string `xml:"email,omitempty"`
 }
 
 type Link struct {
-	Href string
-	Rel  string
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length string `xml:"length,attr,omitempty"`
 }
 
 type Entry struct {
-	Title     string
-	ID        string
-	Link      Link
-	Updated   string
-	Summary   string
-	Content   string
-	Category  Category
-	Published string
-	Rights    string
+	Title      Text       `xml:"title"`
+	ID         string     `xml:"id"`
+	Link       Link       `xml:"-"`
+	Links      []Link     `xml:"link"` // 输出 Atom 时由 Link 和 Enclosure 生成
+	Updated    string     `xml:"updated"`
+	Summary    *Text      `xml:"summary,omitempty"`
+	Content    *Text      `xml:"content,omitempty"`
+	Author     Author     `xml:"author"`
+	Categories []Category `xml:"category"`
+	Published  string     `xml:"published"`
+	Rights     string     `xml:"rights,omitempty"`
+	Enclosure  *Enclosure `xml:"-"`
+	Cover      string     `xml:"-"` // 封面图片地址，JSON Feed 中输出为 image
+}
+
+// Text 是带 type 属性的文本，内容由 encoding/xml 转义
+type Text struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
 }
 
 type Category struct {
-	Label string
-	Term  string
+	Term   string `xml:"term,attr"`
+	Scheme string `xml:"scheme,attr,omitempty"`
+	Label  string `xml:"label,attr,omitempty"`
 }
 
 // 文章时间的逻辑
@@ -54,58 +81,555 @@ func formatPostDate(dateStr string) string {
 
 // feed生成时间的逻辑
 func formatCurrentTime() string {
-	now := time.Now()
+	now := time.Now().UTC()
 	return now.Format("2006-01-02T15:04:05.000Z")
 }
 
-// 生成 Atom feed
-func generateAtomFeed(posts []PostMetadata, config *BlogConfig, outputPath string) error {
-	var builder strings.Builder
-
-	builder.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
-	builder.WriteString("<feed xmlns=\"http://www.w3.org/2005/Atom\">\n")
-	builder.WriteString("<id>" + config.URI + "/</id>\n")
-	builder.WriteString("<title>" + config.Title + "</title>\n")
-	builder.WriteString("<updated>" + formatCurrentTime() + "</updated>\n")
-	builder.WriteString("<generator>DaRM</generator>\n")
-	builder.WriteString("<author><name>" + config.Author + "</name><uri>" + config.URI + "</uri></author>\n")
-	builder.WriteString("<link href=\"" + config.URI + "\" rel=\"alternate\"/>\n")
-	builder.WriteString("<link href=\"" + config.URI + "/feed/\" rel=\"self\"/>\n")
-	builder.WriteString("<subtitle>" + config.Description + "</subtitle>\n")
-	builder.WriteString("<logo>" + config.URI + "/res/image/logo.png</logo>\n")
-	builder.WriteString("<rights>Copyright © 2019 - Now " + config.Title + "</rights>\n")
-
-	sort.Slice(posts, func(i, j int) bool {
-		return posts[i].Date > posts[j].Date
+// 文章作者的逻辑，未指定作者时使用博客作者
+func postFeedAuthor(post PostMetadata, config *BlogConfig) Author {
+	if post.AuthorInfo == nil {
+		return Author{Name: config.Author, URI: config.URI, Email: config.Email}
+	}
+	return Author{
+		Name:  post.AuthorInfo.Name,
+		URI:   config.URI + "/authors/" + post.AuthorInfo.ID + "/",
+		Email: post.AuthorInfo.Email,
+	}
+}
+
+// 文章分类和标签的逻辑，分类和标签分别使用各自页面的地址作为 scheme
+func postFeedCategories(post PostMetadata, config *BlogConfig) []Category {
+	var categories []Category
+	if post.Category != "" {
+		categories = append(categories, Category{Term: post.Category, Scheme: config.URI + "/categories/", Label: post.Category})
+	}
+	for _, tag := range post.Tags {
+		if tag != "" {
+			categories = append(categories, Category{Term: tag, Scheme: config.URI + "/tags/", Label: tag})
+		}
+	}
+	return categories
+}
+
+// 订阅内容的输出方式
+const (
+	feedContentFull    = "full"
+	feedContentSummary = "summary"
+)
+
+// FeedLink 是页面头部中指向订阅的 <link rel="alternate">
+type FeedLink struct {
+	Type  string
+	Href  string
+	Title string
+}
+
+// feedLinks 返回站点启用的订阅格式，base 为订阅目录的地址
+func feedLinks(config *BlogConfig, base, title string) []FeedLink {
+	var links []FeedLink
+	if config.FeedAtom {
+		links = append(links, FeedLink{Type: "application/atom+xml", Href: base + "index.xml", Title: title + " (Atom)"})
+	}
+	if config.FeedRSS {
+		links = append(links, FeedLink{Type: "application/rss+xml", Href: base + "rss.xml", Title: title + " (RSS)"})
+	}
+	if config.FeedJSON {
+		links = append(links, FeedLink{Type: "application/feed+json", Href: base + "feed.json", Title: title + " (JSON Feed)"})
+	}
+	return links
+}
+
+// siteFeedLinks 返回全站订阅的链接，传给各页面的 header.html
+func siteFeedLinks(config *BlogConfig) []FeedLink {
+	return feedLinks(config, config.URI+"/feed/", config.Title)
+}
+
+// generateFeeds 生成全站订阅，输出到 feedDir
+func generateFeeds(posts []PostMetadata, config *BlogConfig, feedDir string) error {
+	feed := buildFeed(posts, config, config.Title, config.URI+"/")
+	return writeFeeds(feed, config, config.URI+"/feed/", feedDir)
+}
+
+// generateSectionFeed 为标签或分类生成订阅，section 为 tags 或 categories，输出到对应页面下的 feed 目录
+func generateSectionFeed(posts []PostMetadata, config *BlogConfig, section, name, label, outputDir string) error {
+	feed := buildFeed(posts, config, config.Title+" - "+label, sectionURL(config, section, name))
+	return writeFeeds(feed, config, sectionURL(config, section, name)+"feed/", filepath.Join(outputDir, section, name, "feed"))
+}
+
+// sectionURL 返回标签或分类页面的地址
+func sectionURL(config *BlogConfig, section, name string) string {
+	return config.URI + "/" + section + "/" + url.PathEscape(name) + "/"
+}
+
+// primaryFeedURL 返回订阅目录中首选格式的订阅地址，未启用任何格式时返回空字符串
+func primaryFeedURL(config *BlogConfig, base string) string {
+	if links := feedLinks(config, base, ""); len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
+
+// writeFeeds 按配置在 feedDir 中写入 Atom、RSS 2.0 和 JSON Feed 订阅，feedURL 为该目录的地址
+func writeFeeds(feed Feed, config *BlogConfig, feedURL, feedDir string) error {
+	if err := os.MkdirAll(feedDir, os.ModePerm); err != nil {
+		return err
+	}
+	if config.FeedAtom {
+		if err := writeAtomFeed(feed, feedURL+"index.xml", filepath.Join(feedDir, "index.xml")); err != nil {
+			return fmt.Errorf("生成 Atom 订阅失败: %v", err)
+		}
+	}
+	if config.FeedRSS {
+		if err := writeRSSFeed(feed, feedURL+"rss.xml", filepath.Join(feedDir, "rss.xml")); err != nil {
+			return fmt.Errorf("生成 RSS 订阅失败: %v", err)
+		}
+	}
+	if config.FeedJSON {
+		if err := writeJSONFeed(feed, feedURL+"feed.json", filepath.Join(feedDir, "feed.json")); err != nil {
+			return fmt.Errorf("生成 JSON Feed 失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// buildFeed 生成各种订阅格式共用的 feed 数据，包含按配置数量的最新文章，homeURL 为订阅对应的页面地址
+func buildFeed(posts []PostMetadata, config *BlogConfig, title, homeURL string) Feed {
+	rights := config.FeedRights
+
+	feed := Feed{
+		ID:        homeURL,
+		Title:     title,
+		Updated:   formatCurrentTime(),
+		Generator: "DaRM",
+		Author:    []Author{{Name: config.Author, URI: config.URI}},
+		Links:     []Link{{Href: homeURL, Rel: "alternate", Type: "text/html"}},
+		Subtitle:  config.Description,
+		Logo:      config.URI + "/res/images/logo.png",
+		Rights:    rights,
+	}
+	if config.Podcast != nil && config.Podcast.Enabled {
+		feed.Podcast = config.Podcast
+	}
+
+	// 在副本上排序，调用方的文章顺序保持不变
+	sorted := append([]PostMetadata(nil), posts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date > sorted[j].Date
 	})
 
-	latestPosts := posts
-	if len(posts) > 10 {
-		latestPosts = posts[:10]
+	var latestPosts []PostMetadata
+	for _, post := range sorted {
+		if config.FeedItems > 0 && len(latestPosts) >= config.FeedItems {
+			break
+		}
+		if includeInFeed(post, config) {
+			latestPosts = append(latestPosts, post)
+		}
 	}
 
 	for _, post := range latestPosts {
-		builder.WriteString("<entry>\n")
-		builder.WriteString("<title type=\"html\"><![CDATA[" + post.Title + "]]></title>\n")
-		builder.WriteString("<id>" + config.URI + "/" + post.URI + "/</id>\n")
-		builder.WriteString("<link href=\"" + config.URI + "/" + post.URI + "/\"/>\n")
-		builder.WriteString("<updated>" + formatPostDate(post.Date) + "</updated>\n")
-		builder.WriteString("<summary type=\"html\"><![CDATA[" + post.Description + "]]></summary>\n")
-		builder.WriteString("<content type=\"html\"><![CDATA[" + convertMarkdownToHTML(post.Content) + "]]></content>\n")
-		builder.WriteString("<category label=\"" + post.Category + "\" term=\"" + post.Category + "\"/>\n")
-		builder.WriteString("<published>" + formatPostDate(post.Date) + "</published>\n")
-		builder.WriteString("<rights>Copyright © 2019 - Now " + config.Title + "</rights>\n")
-		builder.WriteString("</entry>\n")
-	}
-
-	builder.WriteString("</feed>")
-
-	file, err := os.Create(outputPath)
+		postURL := config.URI + "/" + post.URI + "/"
+
+		// 头部的描述是纯文本，转义后与 HTML 摘要和封面一起作为 HTML 输出
+		summary := template.HTMLEscapeString(post.Description)
+		if summary == "" {
+			summary = absoluteURLs(post.Summary, postURL)
+		}
+
+		entry := Entry{
+			Title:      Text{Body: post.Title},
+			ID:         postURL,
+			Link:       Link{Href: postURL, Rel: "alternate", Type: "text/html"},
+			Updated:    formatPostDate(post.Date),
+			Author:     postFeedAuthor(post, config),
+			Categories: postFeedCategories(post, config),
+			Published:  formatPostDate(post.Date),
+			Rights:     rights,
+			Enclosure:  post.Enclosure,
+			Cover:      post.CoverLarge,
+		}
+		if summary != "" {
+			entry.Summary = &Text{Type: "html", Body: summary}
+		}
+		if config.FeedContent != feedContentSummary {
+			content := post.ContentHTML
+			if content == "" {
+				content = convertMarkdownToHTML(post.Content, &post, config, newRenderState())
+			}
+			entry.Content = &Text{Type: "html", Body: absoluteURLs(content, postURL)}
+		}
+
+		// 封面放在正文之前，只输出摘要时放在摘要之前
+		if post.CoverLarge != "" {
+			cover := fmt.Sprintf(`<p><img src="%s" alt="%s"></p>`, template.HTMLEscapeString(post.CoverLarge), template.HTMLEscapeString(post.Title))
+			if entry.Content != nil {
+				entry.Content.Body = cover + entry.Content.Body
+			} else if entry.Summary != nil {
+				entry.Summary.Body = cover + entry.Summary.Body
+			} else {
+				entry.Summary = &Text{Type: "html", Body: cover}
+			}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed
+}
+
+// includeInFeed 判断文章是否出现在订阅中，头部设置 feed: false 或属于排除的分类时不出现
+func includeInFeed(post PostMetadata, config *BlogConfig) bool {
+	if post.InFeed != nil && !*post.InFeed {
+		return false
+	}
+	return !containsString(config.FeedExcludeCategories, strings.ToLower(post.Category))
+}
+
+// absoluteURLs 将 HTML 中相对的链接、图片和 srcset 地址转换为以 base 为基准的绝对地址，便于在阅读器中显示
+func absoluteURLs(content, base string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil || content == "" {
+		return content
+	}
+	resolve := func(value string) string {
+		ref, err := url.Parse(strings.TrimSpace(value))
+		if err != nil || ref.IsAbs() || strings.HasPrefix(value, "#") {
+			return value
+		}
+		return baseURL.ResolveReference(ref).String()
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+	doc.Find("[href], [src]").Each(func(i int, s *goquery.Selection) {
+		for _, attr := range []string{"href", "src"} {
+			if value, ok := s.Attr(attr); ok {
+				s.SetAttr(attr, resolve(value))
+			}
+		}
+	})
+	doc.Find("[srcset]").Each(func(i int, s *goquery.Selection) {
+		value, _ := s.Attr("srcset")
+		candidates := strings.Split(value, ",")
+		for i, candidate := range candidates {
+			fields := strings.Fields(candidate)
+			if len(fields) > 0 {
+				fields[0] = resolve(fields[0])
+				candidates[i] = strings.Join(fields, " ")
+			}
+		}
+		s.SetAttr("srcset", strings.Join(candidates, ", "))
+	})
+
+	result, err := doc.Find("body").Html()
+	if err != nil {
+		return content
+	}
+	return result
+}
+
+// writeAtomFeed 写入 Atom 订阅
+func writeAtomFeed(feed Feed, selfURL, outputPath string) error {
+	feed.Links = append(feed.Links, Link{Href: selfURL, Rel: "self", Type: "application/atom+xml"})
+
+	entries := make([]Entry, len(feed.Entries))
+	for i, entry := range feed.Entries {
+		entry.Links = []Link{entry.Link}
+		if enclosure := entry.Enclosure; enclosure != nil && enclosure.URL != "" {
+			link := Link{Href: enclosure.URL, Rel: "enclosure", Type: enclosure.Type}
+			if enclosure.Length > 0 {
+				link.Length = strconv.FormatInt(enclosure.Length, 10)
+			}
+			entry.Links = append(entry.Links, link)
+		}
+		entries[i] = entry
+	}
+	feed.Entries = entries
+
+	output, err := xml.MarshalIndent(feed, "", "  ")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	return ioutil.WriteFile(outputPath, append([]byte(xml.Header), output...), 0644)
+}
 
-	_, err = file.WriteString(builder.String())
-	return err
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Content string     `xml:"xmlns:content,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	ITunes  string     `xml:"xmlns:itunes,attr,omitempty"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      Link      `xml:"atom:link"`
+	Copyright     string    `xml:"copyright,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Language      string    `xml:"language,omitempty"`
+	Image         *rssImage `xml:"image,omitempty"`
+
+	ITunesAuthor   string          `xml:"itunes:author,omitempty"`
+	ITunesOwner    *itunesOwner    `xml:"itunes:owner,omitempty"`
+	ITunesImage    *itunesImage    `xml:"itunes:image,omitempty"`
+	ITunesCategory *itunesCategory `xml:"itunes:category,omitempty"`
+	ITunesExplicit string          `xml:"itunes:explicit,omitempty"`
+	ITunesType     string          `xml:"itunes:type,omitempty"`
+
+	Items []rssItem `xml:"item"`
+}
+
+type itunesOwner struct {
+	Name  string `xml:"itunes:name,omitempty"`
+	Email string `xml:"itunes:email,omitempty"`
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type itunesCategory struct {
+	Text        string          `xml:"text,attr"`
+	Subcategory *itunesCategory `xml:"itunes:category,omitempty"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssImage struct {
+	URL   string `xml:"url"`
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Description string        `xml:"description,omitempty"`
+	Content     string        `xml:"content:encoded,omitempty"`
+	Author      string        `xml:"author,omitempty"`
+	Creator     string        `xml:"dc:creator,omitempty"`
+	Categories  []rssCategory `xml:"category"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+
+	ITunesDuration string `xml:"itunes:duration,omitempty"`
+}
+
+type rssCategory struct {
+	Domain string `xml:"domain,attr,omitempty"`
+	Name   string `xml:",chardata"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// writeRSSFeed 将 feed 数据转换为 RSS 2.0 格式写入
+func writeRSSFeed(feed Feed, selfURL, outputPath string) error {
+	channel := rssChannel{
+		Title:         feed.Title,
+		Link:          feed.ID,
+		Description:   feed.Subtitle,
+		AtomLink:      Link{Href: selfURL, Rel: "self", Type: "application/rss+xml"},
+		Copyright:     feed.Rights,
+		LastBuildDate: rssDate(feed.Updated),
+		Generator:     feed.Generator,
+	}
+	if channel.Description == "" {
+		channel.Description = feed.Title
+	}
+	if feed.Logo != "" {
+		channel.Image = &rssImage{URL: feed.Logo, Title: feed.Title, Link: feed.ID}
+	}
+
+	for _, entry := range feed.Entries {
+		item := rssItem{
+			Title:   entry.Title.Body,
+			Link:    entry.Link.Href,
+			Creator: entry.Author.Name,
+			GUID:    rssGUID{IsPermaLink: true, Value: entry.ID},
+			PubDate: rssDate(entry.Published),
+		}
+		if entry.Summary != nil {
+			item.Description = entry.Summary.Body
+		}
+		if entry.Content != nil {
+			item.Content = entry.Content.Body
+		}
+		// RSS 的 author 必须是邮箱地址
+		if entry.Author.Email != "" {
+			item.Author = entry.Author.Email + " (" + entry.Author.Name + ")"
+		}
+		for _, category := range entry.Categories {
+			item.Categories = append(item.Categories, rssCategory{Domain: category.Scheme, Name: category.Term})
+		}
+		if enclosure := entry.Enclosure; enclosure != nil && enclosure.URL != "" {
+			item.Enclosure = &rssEnclosure{URL: enclosure.URL, Length: enclosure.Length, Type: enclosure.Type}
+			item.ITunesDuration = enclosure.Duration
+		}
+		channel.Items = append(channel.Items, item)
+	}
+
+	rss := rssFeed{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Content: "http://purl.org/rss/1.0/modules/content/",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: channel,
+	}
+	if feed.Podcast != nil {
+		rss.ITunes = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+		applyPodcastTags(&rss.Channel, feed)
+	}
+	output, err := xml.MarshalIndent(rss, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(outputPath, append([]byte(xml.Header), output...), 0644)
+}
+
+// applyPodcastTags 为 RSS 频道添加 iTunes 播客标签
+func applyPodcastTags(channel *rssChannel, feed Feed) {
+	podcast := feed.Podcast
+
+	if podcast.Title != "" {
+		channel.Title = podcast.Title
+	}
+	channel.Language = podcast.Language
+	channel.ITunesAuthor = podcast.Author
+	if channel.ITunesAuthor == "" && len(feed.Author) > 0 {
+		channel.ITunesAuthor = feed.Author[0].Name
+	}
+	if podcast.Owner != "" || podcast.Email != "" {
+		channel.ITunesOwner = &itunesOwner{Name: podcast.Owner, Email: podcast.Email}
+	}
+	if podcast.Artwork != "" {
+		channel.ITunesImage = &itunesImage{Href: podcast.Artwork}
+	}
+	if podcast.Category != "" {
+		channel.ITunesCategory = &itunesCategory{Text: podcast.Category}
+		if podcast.Subcategory != "" {
+			channel.ITunesCategory.Subcategory = &itunesCategory{Text: podcast.Subcategory}
+		}
+	}
+	channel.ITunesExplicit = strconv.FormatBool(podcast.Explicit)
+	channel.ITunesType = podcast.Type
+}
+
+// rssDate 将 Atom 使用的 RFC 3339 时间转换为 RSS 使用的 RFC 1123 时间
+func rssDate(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return t.Format(time.RFC1123Z)
+}
+
+type jsonFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	FeedURL     string           `json:"feed_url"`
+	Description string           `json:"description,omitempty"`
+	Icon        string           `json:"icon,omitempty"`
+	Authors     []jsonFeedAuthor `json:"authors,omitempty"`
+	Items       []jsonFeedItem   `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html,omitempty"`
+	Summary       string           `json:"summary,omitempty"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Image         string           `json:"image,omitempty"`
+	Attachments   []jsonFeedAttach `json:"attachments,omitempty"`
+}
+
+type jsonFeedAttach struct {
+	URL               string `json:"url"`
+	MimeType          string `json:"mime_type"`
+	SizeInBytes       int64  `json:"size_in_bytes,omitempty"`
+	DurationInSeconds int    `json:"duration_in_seconds,omitempty"`
+}
+
+// writeJSONFeed 将 feed 数据转换为 JSON Feed 1.1 格式写入
+func writeJSONFeed(feed Feed, feedURL, outputPath string) error {
+	output := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.ID,
+		FeedURL:     feedURL,
+		Description: feed.Subtitle,
+		Icon:        feed.Logo,
+		Items:       []jsonFeedItem{},
+	}
+	for _, author := range feed.Author {
+		output.Authors = append(output.Authors, jsonFeedAuthor{Name: author.Name, URL: author.URI})
+	}
+
+	for _, entry := range feed.Entries {
+		item := jsonFeedItem{
+			ID:            entry.ID,
+			URL:           entry.Link.Href,
+			Title:         entry.Title.Body,
+			DatePublished: entry.Published,
+			DateModified:  entry.Updated,
+			Authors:       []jsonFeedAuthor{{Name: entry.Author.Name, URL: entry.Author.URI}},
+			Image:         entry.Cover,
+		}
+		if entry.Content != nil {
+			item.ContentHTML = entry.Content.Body
+		}
+		// JSON Feed 的 summary 为纯文本
+		if entry.Summary != nil {
+			item.Summary = plainText(entry.Summary.Body)
+		}
+		for _, category := range entry.Categories {
+			if !containsString(item.Tags, category.Term) {
+				item.Tags = append(item.Tags, category.Term)
+			}
+		}
+		if enclosure := entry.Enclosure; enclosure != nil && enclosure.URL != "" {
+			seconds, _ := durationSeconds(enclosure.Duration)
+			item.Attachments = []jsonFeedAttach{{URL: enclosure.URL, MimeType: enclosure.Type, SizeInBytes: enclosure.Length, DurationInSeconds: seconds}}
+		}
+		output.Items = append(output.Items, item)
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(outputPath, data, 0644)
+}
+
+// plainText 去掉 HTML 标签，返回其中的文字
+func plainText(source string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(source))
+	if err != nil {
+		return source
+	}
+	return strings.TrimSpace(doc.Text())
 }
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// rssTestFeed 只包含测试需要检查的 RSS 字段
+type rssTestFeed struct {
+	Version string `xml:"version,attr"`
+	Channel struct {
+		Title    string `xml:"title"`
+		AtomLink struct {
+			Href string `xml:"href,attr"`
+		} `xml:"http://www.w3.org/2005/Atom link"`
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+			Author      string `xml:"author"`
+			PubDate     string `xml:"pubDate"`
+			Categories  []struct {
+				Domain string `xml:"domain,attr"`
+				Name   string `xml:",chardata"`
+			} `xml:"category"`
+			Enclosure *struct {
+				URL    string `xml:"url,attr"`
+				Length int64  `xml:"length,attr"`
+				Type   string `xml:"type,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+func feedTestPosts() []PostMetadata {
+	noFeed := false
+	return []PostMetadata{
+		{
+			Title: "旧文章 & 说明", URI: "old", Date: "2024-01-01", Category: "技术", Tags: []string{"go"},
+			ContentHTML: `<p>正文 <a href="/other/">链接</a> <img src="a.png"></p>`,
+			Summary:     `<p>摘要</p>`,
+			Enclosure:   &Enclosure{URL: "https://example.com/a.mp3", Type: "audio/mpeg", Length: 1024},
+		},
+		{Title: "新文章", URI: "new", Date: "2024-03-01", Category: "技术", ContentHTML: "<p>新</p>", Description: "描述 <A & B>"},
+		{Title: "不订阅", URI: "hidden", Date: "2024-04-01", InFeed: &noFeed, ContentHTML: "<p>x</p>"},
+		{Title: "草稿", URI: "draft", Date: "2024-05-01", Category: "Drafts", ContentHTML: "<p>x</p>"},
+	}
+}
+
+func TestGenerateFeeds(t *testing.T) {
+	dir := t.TempDir()
+	config := &BlogConfig{
+		Title: "博客", URI: "https://example.com", Author: "作者", Email: "me@example.com",
+		FeedAtom: true, FeedRSS: true, FeedJSON: true, FeedItems: 10, FeedContent: feedContentFull,
+		FeedExcludeCategories: []string{"drafts"},
+	}
+	if err := generateFeeds(feedTestPosts(), config, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Atom
+	data, err := ioutil.ReadFile(filepath.Join(dir, "index.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), `<?xml version="1.0" encoding="UTF-8"?>`) {
+		t.Errorf("Atom 订阅缺少 XML 声明")
+	}
+	var atom Feed
+	if err := xml.Unmarshal(data, &atom); err != nil {
+		t.Fatalf("Atom 订阅不是有效的 XML: %v", err)
+	}
+	var titles []string
+	for _, entry := range atom.Entries {
+		titles = append(titles, entry.Title.Body)
+	}
+	if want := []string{"新文章", "旧文章 & 说明"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("Atom 条目为 %v，期望 %v", titles, want)
+	}
+	if !containsLink(atom.Links, "self", "https://example.com/feed/index.xml") {
+		t.Errorf("Atom 订阅缺少 self 链接: %+v", atom.Links)
+	}
+	if s := atom.Entries[0].Summary; s == nil || s.Body != "描述 &lt;A &amp; B&gt;" {
+		t.Errorf("Atom 摘要中的描述应转义: %+v", s)
+	}
+	old := atom.Entries[1]
+	if !containsLink(old.Links, "enclosure", "https://example.com/a.mp3") {
+		t.Errorf("Atom 条目缺少附件链接: %+v", old.Links)
+	}
+	if old.Content == nil || !strings.Contains(old.Content.Body, `href="https://example.com/other/"`) || !strings.Contains(old.Content.Body, `src="https://example.com/old/a.png"`) {
+		t.Errorf("Atom 正文中的地址应为绝对地址: %+v", old.Content)
+	}
+	if old.Published != "2024-01-01T20:00:00.000Z" {
+		t.Errorf("Atom 发布时间为 %s", old.Published)
+	}
+
+	// RSS
+	data, err = ioutil.ReadFile(filepath.Join(dir, "rss.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rss rssTestFeed
+	if err := xml.Unmarshal(data, &rss); err != nil {
+		t.Fatalf("RSS 订阅不是有效的 XML: %v", err)
+	}
+	if rss.Version != "2.0" || rss.Channel.AtomLink.Href != "https://example.com/feed/rss.xml" {
+		t.Errorf("RSS 版本或 self 链接不正确: %s %s", rss.Version, rss.Channel.AtomLink.Href)
+	}
+	if len(rss.Channel.Items) != 2 {
+		t.Fatalf("RSS 中有 %d 个条目，期望 2 个", len(rss.Channel.Items))
+	}
+	item := rss.Channel.Items[1]
+	if item.PubDate != "Mon, 01 Jan 2024 20:00:00 +0000" {
+		t.Errorf("RSS 发布时间为 %s", item.PubDate)
+	}
+	if item.Author != "me@example.com (作者)" {
+		t.Errorf("RSS 作者为 %s", item.Author)
+	}
+	if item.Description != "<p>摘要</p>" || !strings.Contains(item.Content, "<p>正文 ") {
+		t.Errorf("RSS 摘要或正文不正确: %q %q", item.Description, item.Content)
+	}
+	if item.Enclosure == nil || item.Enclosure.Length != 1024 || item.Enclosure.Type != "audio/mpeg" {
+		t.Errorf("RSS 附件不正确: %+v", item.Enclosure)
+	}
+	if len(item.Categories) != 2 || item.Categories[0].Domain != "https://example.com/categories/" {
+		t.Errorf("RSS 分类不正确: %+v", item.Categories)
+	}
+
+	// JSON Feed
+	data, err = ioutil.ReadFile(filepath.Join(dir, "feed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jf jsonFeed
+	if err := json.Unmarshal(data, &jf); err != nil {
+		t.Fatalf("JSON Feed 无效: %v", err)
+	}
+	if jf.Version != "https://jsonfeed.org/version/1.1" || len(jf.Items) != 2 || jf.Items[0].Summary != "描述 <A & B>" {
+		t.Errorf("JSON Feed 内容不正确: %+v", jf)
+	}
+}
+
+func TestGenerateFeedsOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		config BlogConfig
+		files  []string
+		item   string // 条目的标签
+		items  int
+	}{
+		{name: "只输出 RSS", config: BlogConfig{FeedRSS: true}, files: []string{"rss.xml"}, item: "<item>", items: 3},
+		{name: "限制条目数量", config: BlogConfig{FeedAtom: true, FeedItems: 1}, files: []string{"index.xml"}, item: "<entry>", items: 1},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		config := tt.config
+		config.URI = "https://example.com"
+		config.FeedContent = feedContentSummary
+		if err := generateFeeds(feedTestPosts(), &config, dir); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		files, _ := filepath.Glob(filepath.Join(dir, "*"))
+		var names []string
+		for _, file := range files {
+			names = append(names, filepath.Base(file))
+		}
+		if !reflect.DeepEqual(names, tt.files) {
+			t.Errorf("%s: 生成了 %v，期望 %v", tt.name, names, tt.files)
+		}
+
+		data, _ := ioutil.ReadFile(files[0])
+		if count := strings.Count(string(data), tt.item); count != tt.items {
+			t.Errorf("%s: 有 %d 个条目，期望 %d 个", tt.name, count, tt.items)
+		}
+		if strings.Contains(string(data), "正文") {
+			t.Errorf("%s: 只输出摘要时不应包含正文", tt.name)
+		}
+	}
+}
+
+// containsLink 判断链接中是否有指定 rel 和地址的链接
+func containsLink(links []Link, rel, href string) bool {
+	for _, link := range links {
+		if link.Rel == rel && link.Href == href {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBuildFeedKeepsOrder(t *testing.T) {
+	posts := []PostMetadata{
+		{Title: "a", URI: "a", Date: "2024-01-01"},
+		{Title: "b", URI: "b", Date: "2024-03-01"},
+		{Title: "c", URI: "c", Date: "2024-03-01"},
+	}
+	feed := buildFeed(posts, &BlogConfig{URI: "https://example.com"}, "博客", "https://example.com/")
+
+	var titles []string
+	for _, entry := range feed.Entries {
+		titles = append(titles, entry.Title.Body)
+	}
+	if want := []string{"b", "c", "a"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("订阅条目为 %v，期望 %v", titles, want)
+	}
+	if posts[0].Title != "a" || posts[1].Title != "b" {
+		t.Errorf("buildFeed 不应改变传入文章的顺序: %v", posts)
+	}
+}
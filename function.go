@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -20,7 +21,39 @@ type PostMetadata struct {
 	TagsStr     string
 	Date        string
 	URI         string
-	Content     string // 新增字段用于存储 Markdown 正文
+	Author      string     // 作者 ID，对应 authors.config 中的键
+	Aliases     []string   `yaml:"aliases"`   // 旧的 URI，生成时会在这些地址写入跳转页
+	Trusted     bool       `yaml:"trusted"`   // 可信文章不做 HTML 过滤
+	InFeed      *bool      `yaml:"feed"`      // 设置为 false 时不出现在订阅中
+	Enclosure   *Enclosure `yaml:"enclosure"` // 音频或视频附件
+	InSitemap   *bool      `yaml:"sitemap"`   // 设置为 false 时不出现在站点地图中
+	Cover       string     `yaml:"cover"`     // 封面图片，可以是站内路径或完整地址
+	NoIndex     bool       `yaml:"noindex"`   // 不希望被搜索引擎收录，同时不出现在站点地图和搜索索引中
+	Content     string     // 新增字段用于存储 Markdown 正文
+
+	Bibliography  string `yaml:"bibliography"`   // data/bibliography 下的 BibTeX 或 CSL-JSON 文件
+	CitationStyle string `yaml:"citation-style"` // numeric 或 author-year，未设置时使用站点配置
+
+	FileName    string                 `yaml:"-"` // 文章所在的文件名
+	ContentLine int                    `yaml:"-"` // 正文开始的行号，用于诊断信息定位
+	AuthorInfo  *AuthorProfile         `yaml:"-"` // 根据 Author 解析出的作者信息
+	Params      map[string]interface{} `yaml:"-"` // 头部中未定义的其他字段
+
+	ContentHTML string `yaml:"-"` // 渲染后的正文 HTML
+	Summary     string `yaml:"-"` // 摘要 HTML
+	HasMore     bool   `yaml:"-"` // 摘要之后是否还有内容
+	WordCount   int    `yaml:"-"` // 字数，中日韩文字按字计数，拉丁文字按单词计数
+	ReadingTime int    `yaml:"-"` // 预计阅读分钟数
+
+	Backlinks  []PostRef `yaml:"-"` // 链接到本文的其他文章
+	HasMermaid bool      `yaml:"-"` // 正文中有需要在浏览器中渲染的 mermaid 图表
+
+	Dependencies []string `yaml:"-"` // 正文通过 include 指令用到的片段文件
+	SourceLines  []int    `yaml:"-"` // 展开 include 后正文每一行对应的原文行，用于诊断信息定位
+
+	ShareCard      string `yaml:"-"` // 成功生成的分享卡片地址
+	CoverThumbnail string `yaml:"-"` // 列表页面使用的封面缩略图地址
+	CoverLarge     string `yaml:"-"` // 文章页面、订阅和分享使用的通栏封面地址
 }
 
 // BlogConfig 用于存储从.env文件中读取的博客配置
@@ -32,6 +65,60 @@ type BlogConfig struct {
 	Author      string
 	Email       string
 	CommentUri  string
+
+	RelatedPosts  int // 相关文章数量
+	SummaryLength int // 自动摘要的字符数
+
+	ReadingSpeedCJK   int // 每分钟阅读的中日韩字数
+	ReadingSpeedLatin int // 每分钟阅读的拉丁单词数
+
+	RedirectsFile  bool // 是否生成 _redirects 跳转文件
+	NginxRedirects bool // 是否生成 nginx 跳转规则
+
+	ImageWidths []int  // 响应式图片的宽度
+	ImageSizes  string // 响应式图片的 sizes 属性
+
+	ExternalLinkRel    []string // 站外链接的 rel 值
+	ExternalLinkTarget string   // 站外链接的 target
+	TrustedDomains     []string // 可信域名，链接不添加 nofollow、ugc、sponsored
+
+	Sanitize      bool     // 是否过滤文章中的 HTML
+	SanitizeTags  []string // 过滤时允许的标签
+	SanitizeAttrs []string // 过滤时允许的属性
+
+	DiagramCommands map[string]string // 图表类型对应的本地转换命令，未配置的类型不在生成时转换
+	MermaidScript   string            // 浏览器渲染 mermaid 图表时加载的脚本地址
+
+	CitationStyle     string // 默认的引用样式
+	BibliographyTitle string // 参考文献列表的标题
+
+	FeedAtom bool // 是否生成 Atom 订阅
+	FeedRSS  bool // 是否生成 RSS 2.0 订阅
+	FeedJSON bool // 是否生成 JSON Feed 订阅
+
+	FeedItems             int      // 订阅中的文章数量，0 表示全部
+	FeedContent           string   // full 输出全文，summary 只输出摘要
+	FeedRights            string   // 订阅的版权声明
+	FeedExcludeCategories []string // 不出现在订阅中的分类
+
+	Podcast *PodcastConfig // data/config/podcast.config 中的播客配置
+
+	SitemapImages bool          // 站点地图中是否包含文章图片
+	Robots        *RobotsConfig // data/config/robots.config 中的 robots 规则
+	NoIndexPages  []string      // 不希望被收录的页面类型：index、tag、category、author、search
+
+	SEOImage    string // 页面没有图片时 Open Graph 和 Twitter 卡片使用的默认图片
+	TwitterSite string // 站点的 Twitter 账号，例如 @darm
+
+	ShareCard           bool   // 是否为每篇文章生成 og.png 分享卡片，设置了字体时默认开启
+	ShareCardBackground string // 分享卡片背景，#rrggbb 颜色或站内图片路径
+	ShareCardColor      string // 分享卡片文字颜色
+	ShareCardFont       string // 分享卡片字体文件，中文标题需要设置包含中文的字体
+
+	CoverThumbnailWidth int // 封面缩略图的宽度
+	CoverWidth          int // 通栏封面的宽度
+
+	ConfigProblems []Diagnostic // 无法读取而改用默认值的配置文件
 }
 
 type TagsData struct {
@@ -39,7 +126,7 @@ type TagsData struct {
 }
 
 // 读取并解析 Markdown 文件中的头部信息及正文内容
-func ReadPostMetadata(postPath string) ([]PostMetadata, error) {
+func ReadPostMetadata(postPath string, config *BlogConfig) ([]PostMetadata, error) {
 	install()
 
 	var posts []PostMetadata
@@ -67,11 +154,20 @@ func ReadPostMetadata(postPath string) ([]PostMetadata, error) {
 				continue
 			}
 
+			metadata.FileName = file.Name()
+			metadata.Params = extractParams(sections[1])
+
 			// 将标签数组转换为逗号分隔的字符串
 			if len(metadata.Tags) > 0 {
 				metadata.TagsStr = strings.Join(metadata.Tags, ",")
 			}
 			metadata.Content = sections[2] // 存储正文内容
+			metadata.ContentLine = strings.Count(sections[0]+sections[1], "\n") + 1
+
+			// 统计字数并按配置的阅读速度估算阅读时间
+			cjk, latin := countWords(metadata.Content)
+			metadata.WordCount = cjk + latin
+			metadata.ReadingTime = estimateReadingTime(cjk, latin, config.ReadingSpeedCJK, config.ReadingSpeedLatin)
 			posts = append(posts, metadata)
 		}
 	}
@@ -96,10 +192,125 @@ func LoadBlogConfig(envPath string) (*BlogConfig, error) {
 	config.Author = os.Getenv("BLOG_AUTHOR")
 	config.Email = os.Getenv("EMAIL")
 	config.CommentUri = os.Getenv("COMMENT_URI")
+	config.RelatedPosts = envInt("RELATED_POSTS", 5)
+	config.SummaryLength = envInt("SUMMARY_LENGTH", 200)
+	config.ReadingSpeedCJK = envInt("READING_SPEED_CJK", 300)
+	config.ReadingSpeedLatin = envInt("READING_SPEED_LATIN", 200)
+	config.RedirectsFile = envBool("REDIRECTS_FILE", false)
+	config.NginxRedirects = envBool("NGINX_REDIRECTS", false)
+	config.ImageWidths = envInts("IMAGE_WIDTHS", []int{480, 960, 1440})
+	config.ImageSizes = envString("IMAGE_SIZES", "(max-width: 960px) 100vw, 960px")
+	config.ExternalLinkRel = parseLinkRel(envString("EXTERNAL_LINK_REL", "noopener"))
+	config.ExternalLinkTarget = envString("EXTERNAL_LINK_TARGET", "_blank")
+	config.TrustedDomains = envList("TRUSTED_DOMAINS")
+	config.Sanitize = envBool("SANITIZE_HTML", false)
+	config.SanitizeTags = envList("SANITIZE_TAGS")
+	if len(config.SanitizeTags) == 0 {
+		config.SanitizeTags = defaultSanitizeTags
+	}
+	config.SanitizeAttrs = envList("SANITIZE_ATTRS")
+	if len(config.SanitizeAttrs) == 0 {
+		config.SanitizeAttrs = defaultSanitizeAttrs
+	}
+	config.DiagramCommands = map[string]string{
+		"dot":     envString("DIAGRAM_DOT_COMMAND", ""),
+		"mermaid": envString("DIAGRAM_MERMAID_COMMAND", ""),
+	}
+	config.CitationStyle = envString("CITATION_STYLE", citationNumeric)
+	config.BibliographyTitle = envString("BIBLIOGRAPHY_TITLE", "参考文献")
+	config.FeedAtom = envBool("FEED_ATOM", true)
+	config.FeedRSS = envBool("FEED_RSS", true)
+	config.FeedJSON = envBool("FEED_JSON", true)
+	config.FeedItems = envInt("FEED_ITEMS", 10)
+	config.FeedContent = envString("FEED_CONTENT", feedContentFull)
+	config.FeedRights = envString("FEED_RIGHTS", "Copyright © 2019 - Now "+config.Title)
+	config.FeedExcludeCategories = envList("FEED_EXCLUDE_CATEGORIES")
+	config.SitemapImages = envBool("SITEMAP_IMAGES", false)
+	// 附加配置文件有误时使用默认配置继续生成，问题由 darm check 报告
+	podcast, err := LoadPodcastConfig(filepath.Join(filepath.Dir(envPath), "config", "podcast.config"))
+	if err != nil {
+		log.Printf("读取播客配置失败，不输出播客信息: %v", err)
+		config.ConfigProblems = append(config.ConfigProblems, Diagnostic{"config/podcast.config", levelError, err.Error()})
+		podcast = &PodcastConfig{}
+	}
+	podcast.Artwork = podcastArtwork(podcast, &config)
+	config.Podcast = podcast
+	robots, err := LoadRobotsConfig(filepath.Join(filepath.Dir(envPath), "config", "robots.config"))
+	if err != nil {
+		log.Printf("读取 robots 配置失败，允许所有爬虫: %v", err)
+		config.ConfigProblems = append(config.ConfigProblems, Diagnostic{"config/robots.config", levelError, err.Error()})
+		robots = &RobotsConfig{Rules: []RobotsRule{{}}}
+	}
+	config.Robots = robots
+	config.NoIndexPages = envList("NOINDEX_PAGES")
+	config.SEOImage = envString("SEO_IMAGE", config.URI+"/res/images/logo.png")
+	config.TwitterSite = envString("TWITTER_SITE", "")
+	config.ShareCardFont = envString("SHARE_CARD_FONT", "")
+	// 内置字体不含中文，未设置字体时默认不生成分享卡片
+	config.ShareCard = envBool("SHARE_CARD", config.ShareCardFont != "")
+	config.ShareCardBackground = envString("SHARE_CARD_BACKGROUND", "#1f2937")
+	config.ShareCardColor = envString("SHARE_CARD_COLOR", "#ffffff")
+	config.CoverThumbnailWidth = envInt("COVER_THUMBNAIL_WIDTH", 480)
+	config.CoverWidth = envInt("COVER_WIDTH", 1440)
+	config.MermaidScript = envString("MERMAID_SCRIPT", "https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.min.js")
 
 	return &config, nil
 }
 
+// envInt 读取整数类型的环境变量，未设置或无法解析时返回默认值
+func envInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// envBool 读取布尔类型的环境变量，未设置或无法解析时返回默认值
+func envBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// envString 读取字符串类型的环境变量，未设置时返回默认值
+func envString(key string, defaultValue string) string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	return value
+}
+
+// envInts 读取逗号分隔的整数列表，未设置时返回默认值，设置为空字符串时返回空列表
+func envInts(key string, defaultValue []int) []int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(item)); err == nil {
+			values = append(values, n)
+		}
+	}
+	return values
+}
+
+// envList 读取逗号分隔的字符串列表，去掉空白和空项并转为小写
+func envList(key string) []string {
+	var values []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
 func ReadTags(postPath string) (*TagsData, error) {
 	tagsData := &TagsData{}
 	tagSet := make(map[string]bool) // 使用 set 结构去重
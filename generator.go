@@ -27,7 +27,7 @@ func generateBlogPages() bool {
 	}
 
 	// 读取所有文章的元数据并排序
-	posts, err := ReadPostMetadata("./data/posts")
+	posts, err := ReadPostMetadata("./data/posts", BlogConfig)
 	if err != nil {
 		success = false
 
@@ -39,6 +39,23 @@ func generateBlogPages() bool {
 		return posts[i].Date > posts[j].Date
 	})
 
+	// 读取作者信息
+	authors, err := LoadAuthors("./data/config/authors.config")
+	if err != nil {
+		log.Printf("读取作者信息失败: %v", err)
+		authors = make(map[string]*AuthorProfile)
+	}
+	resolvePostAuthors(posts, authors)
+
+	// 渲染文章正文和摘要
+	state := renderPosts(posts, BlogConfig, false)
+	for _, d := range state.diagnostics {
+		log.Printf("%s: %s: %s", d.File, d.Level, d.Message)
+	}
+	if err := writeDependencyManifest(posts); err != nil {
+		log.Printf("写入片段依赖记录失败: %v", err)
+	}
+
 	funcMap := template.FuncMap{
 		"safeHTML": safeHTML,
 		"add":      func(x, y int) int { return x + y },
@@ -71,7 +88,7 @@ func generateBlogPages() bool {
 	}
 
 	//菜单生成
-	menuHTML := ReadMenuConfig("./data/config/menu.config")
+	menuHTML := ReadMenuConfig("./data/config/menu.config", BlogConfig)
 
 	// 生成主页页面
 	totalPages := (len(posts) + postsPerPage - 1) / postsPerPage
@@ -102,10 +119,15 @@ func generateBlogPages() bool {
 			"BlogURI":         BlogConfig.URI,
 			"BlogTags":        BlogConfig.Tags,
 			"BlogAuthor":      BlogConfig.Author,
+			"FeedLinks":       siteFeedLinks(BlogConfig),
 			"Menu":            menuHTML,
 			"Posts":           pagePosts,
 			"CurrentPage":     pageIndex + 1,
 			"TotalPages":      totalPages,
+			"SEO":             pageSEO(BlogConfig, BlogConfig.Title, BlogConfig.Description, pageURL(BlogConfig.URI+"/", pageIndex+1)),
+			"Robots":          pageRobots(BlogConfig, "index", nil),
+			"Mermaid":         summariesHaveMermaid(pagePosts),
+			"MermaidScript":   BlogConfig.MermaidScript,
 			"PageType":        "index",
 		}
 
@@ -117,9 +139,16 @@ func generateBlogPages() bool {
 	}
 
 	// 生成每篇文章的页面
-	for _, post := range posts {
+	for i, post := range posts {
 		postDir := "./data/public/" + post.URI
 		os.MkdirAll(postDir, os.ModePerm)
+		if BlogConfig.ShareCard {
+			if err := generateShareCard(post, BlogConfig, postDir+"/og.png"); err != nil {
+				log.Printf("生成文章 %s 的分享卡片失败，使用封面或正文图片: %v", post.URI, err)
+			} else {
+				post.ShareCard = shareCardURL(post, BlogConfig)
+			}
+		}
 		postPath := postDir + "/index.html"
 		postFile, err := os.Create(postPath)
 		if err != nil {
@@ -127,24 +156,42 @@ func generateBlogPages() bool {
 		}
 		defer postFile.Close()
 
-		post.Content = convertMarkdownToHTML(post.Content)
+		neighbors := findPostNeighbors(posts, i, BlogConfig.RelatedPosts)
 		err = tmpl.ExecuteTemplate(postFile, "post.html", map[string]interface{}{
-			"Title":           post.Title,
-			"Content":         post.Content,
-			"URI":             post.URI,
-			"Description":     post.Description,
-			"Category":        post.Category,
-			"Date":            post.Date,
-			"TagsArray":       post.Tags,
-			"Tags":            post.TagsStr,
-			"Menu":            menuHTML,
-			"BlogTitle":       BlogConfig.Title,
-			"BlogDescription": BlogConfig.Description,
-			"BlogURI":         BlogConfig.URI,
-			"BlogTags":        BlogConfig.Tags,
-			"BlogAuthor":      BlogConfig.Author,
-			"BlogCommentUri":  BlogConfig.CommentUri,
-			"PageType":        "post",
+			"Title":              post.Title,
+			"Content":            post.ContentHTML,
+			"Summary":            post.Summary,
+			"WordCount":          post.WordCount,
+			"ReadingTime":        post.ReadingTime,
+			"URI":                post.URI,
+			"Description":        post.Description,
+			"Category":           post.Category,
+			"Date":               post.Date,
+			"TagsArray":          post.Tags,
+			"Tags":               post.TagsStr,
+			"Menu":               menuHTML,
+			"BlogTitle":          BlogConfig.Title,
+			"BlogDescription":    BlogConfig.Description,
+			"BlogURI":            BlogConfig.URI,
+			"BlogTags":           BlogConfig.Tags,
+			"BlogAuthor":         BlogConfig.Author,
+			"FeedLinks":          siteFeedLinks(BlogConfig),
+			"BlogCommentUri":     BlogConfig.CommentUri,
+			"Author":             post.AuthorInfo,
+			"Params":             post.Params,
+			"PrevPost":           neighbors.PrevPost,
+			"NextPost":           neighbors.NextPost,
+			"PrevPostInCategory": neighbors.PrevPostInCategory,
+			"NextPostInCategory": neighbors.NextPostInCategory,
+			"RelatedPosts":       neighbors.RelatedPosts,
+			"Backlinks":          post.Backlinks,
+			"Enclosure":          post.Enclosure,
+			"Cover":              post.CoverLarge,
+			"Mermaid":            post.HasMermaid,
+			"MermaidScript":      BlogConfig.MermaidScript,
+			"Robots":             postRobots(post),
+			"SEO":                postSEO(post, BlogConfig),
+			"PageType":           "post",
 		})
 		if err != nil {
 			continue
@@ -152,6 +199,18 @@ func generateBlogPages() bool {
 		success = true // 假设大多数情况下都成功
 	}
 
+	//生成旧地址的跳转页
+	if err := generateRedirects(posts, BlogConfig, "./data/public"); err != nil {
+		success = false
+		log.Printf("生成跳转页失败: %v", err)
+	}
+
+	//生成作者页面
+	if err := GenerateAuthorPages(posts, authors, BlogConfig, "./data/templates", "./data/public"); err != nil {
+		success = false
+		log.Printf("生成作者页面失败: %v", err)
+	}
+
 	//复制主题模板下的res静态文件文件夹
 	resSrcPath := "./data/templates/res"
 	resDstPath := "./data/public/res"
@@ -160,6 +219,20 @@ func generateBlogPages() bool {
 		log.Fatalf("复制资源失败: %v", err)
 	}
 
+	//复制 static 目录下的图片等静态文件到站点根目录
+	if _, err := os.Stat("./data/static"); err == nil {
+		if err := copyDir("./data/static", "./data/public"); err != nil {
+			success = false
+			log.Printf("复制静态文件失败: %v", err)
+		}
+	}
+
+	//复制响应式图片
+	if err := copyImageVariants(state.imageVariants, "./data/public"); err != nil {
+		success = false
+		log.Printf("复制缩放图片失败: %v", err)
+	}
+
 	//生成feed订阅文件。
 	// 确保 /public/feed 目录存在
 	feedDir := "./data/public/feed"
@@ -168,11 +241,10 @@ func generateBlogPages() bool {
 		log.Fatalf("为feed 创建目录失败: %v", err)
 	}
 
-	// 生成 Atom feed，输出到 /public/feed/index.xml
-	atomFeedPath := filepath.Join(feedDir, "index.xml")
-	if err := generateAtomFeed(posts, BlogConfig, atomFeedPath); err != nil {
+	// 生成 Atom、RSS 和 JSON Feed 订阅，输出到 /public/feed/ 目录
+	if err := generateFeeds(posts, BlogConfig, feedDir); err != nil {
 		success = false
-		log.Fatalf("生成Atom Feed失败:  %v", err)
+		log.Fatalf("生成订阅失败: %v", err)
 	}
 
 	// 生成站点地图
@@ -182,9 +254,8 @@ func generateBlogPages() bool {
 		log.Fatalf("生成站点地图失败: %v", err)
 	}
 
-	//生成 tag 页面
-	posts, err = ReadPostMetadataAndFillTagMap("./data/posts")
-	if err != nil {
+	//生成 tag 页面，标签映射由读取过程填充，页面使用已渲染的文章
+	if _, err := ReadPostMetadataAndFillTagMap("./data/posts"); err != nil {
 		success = false
 		log.Fatalf("读取文章元数据失败: %v", err)
 	}
@@ -192,8 +263,7 @@ func generateBlogPages() bool {
 	GenerateTagPages(posts, BlogConfig, "./data/templates", "./data/public")
 
 	//生成分类页面
-	posts, err = ReadPostMetadataAndFillCategoryMap("./data/posts")
-	if err != nil {
+	if _, err := ReadPostMetadataAndFillCategoryMap("./data/posts"); err != nil {
 		success = false
 		log.Fatalf("读取文章元数据失败 %v", err)
 	}
@@ -320,24 +390,3 @@ func clearDir(dir string) error {
 
 	return nil
 }
-
-func generateRobotsTxt(posts []PostMetadata, blogconfigs *BlogConfig, outputPath string) error {
-	// 定义 robots.txt 的内容
-	robotsContent := `User-agent: *
-Disallow: 
-Sitemap:` + blogconfigs.URI + `/sitemap.xml`
-
-	// 创建并写入文件
-	file, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("创建文件失败: %v", err)
-	}
-	defer file.Close()
-
-	_, err = file.WriteString(robotsContent)
-	if err != nil {
-		return fmt.Errorf("写入文件失败: %v", err)
-	}
-
-	return nil
-}
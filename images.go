@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/image/draw"
+)
+
+// imageCacheDir 存放缩放后的图片，内容不变时跨次生成复用
+const imageCacheDir = "./data/cache/images"
+
+// processImages 为正文中的本地 JPEG/PNG 图片生成多种宽度，并补充 srcset、sizes、宽高和懒加载属性
+func processImages(doc *goquery.Document, config *BlogConfig, state *renderState) {
+	doc.Find("img[src]").Each(func(i int, s *goquery.Selection) {
+		s.SetAttr("loading", "lazy")
+
+		src, _ := s.Attr("src")
+		assetPath, ok := sitePath(src, config)
+		if !ok || len(config.ImageWidths) == 0 || state.checkOnly {
+			return
+		}
+
+		ext := strings.ToLower(path.Ext(assetPath))
+		if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
+			return
+		}
+
+		width, height, variants, err := resizeImage(localAssetPath(assetPath), assetPath, config.ImageWidths)
+		if err != nil {
+			log.Printf("处理图片 %s 失败: %v", src, err)
+			return
+		}
+
+		var srcset []string
+		for _, variant := range variants {
+			state.imageVariants[variant.path] = variant.cache
+			variantURL := (&url.URL{Path: variant.path}).EscapedPath()
+			srcset = append(srcset, fmt.Sprintf("%s/%s %dw", config.URI, variantURL, variant.width))
+		}
+		srcset = append(srcset, fmt.Sprintf("%s %dw", src, width))
+
+		s.SetAttr("srcset", strings.Join(srcset, ", "))
+		s.SetAttr("sizes", config.ImageSizes)
+		s.SetAttr("width", strconv.Itoa(width))
+		s.SetAttr("height", strconv.Itoa(height))
+	})
+}
+
+type imageVariant struct {
+	path  string
+	width int
+	cache string // 缓存中的文件路径
+}
+
+// resizeImage 将图片缩放到比原图窄的各个宽度，结果按图片内容哈希缓存，返回原图尺寸和生成的图片
+func resizeImage(sourcePath, assetPath string, widths []int) (int, int, []imageVariant, error) {
+	data, err := ioutil.ReadFile(sourcePath)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	imgConfig, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("读取图片尺寸失败: %v", err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:8])
+	ext := path.Ext(assetPath)
+	base := strings.TrimSuffix(assetPath, ext)
+
+	var src image.Image
+	var variants []imageVariant
+	for _, width := range widths {
+		if width <= 0 || width >= imgConfig.Width {
+			continue
+		}
+
+		cachePath := filepath.Join(imageCacheDir, fmt.Sprintf("%s-%d%s", hash, width, ext))
+		if _, err := os.Stat(cachePath); os.IsNotExist(err) {
+			// 只有缓存缺失时才解码原图
+			if src == nil {
+				src, _, err = image.Decode(bytes.NewReader(data))
+				if err != nil {
+					return 0, 0, nil, fmt.Errorf("解码图片失败: %v", err)
+				}
+			}
+			height := imgConfig.Height * width / imgConfig.Width
+			if err := writeResizedImage(src, width, height, format, cachePath); err != nil {
+				return 0, 0, nil, err
+			}
+		}
+
+		variantPath := fmt.Sprintf("%s-%dw%s", base, width, ext)
+		variants = append(variants, imageVariant{path: variantPath, width: width, cache: cachePath})
+	}
+
+	return imgConfig.Width, imgConfig.Height, variants, nil
+}
+
+// writeResizedImage 缩放图片并按原格式写入缓存
+func writeResizedImage(src image.Image, width, height int, format, cachePath string) error {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.Create(cachePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if format == "png" {
+		return png.Encode(file, dst)
+	}
+	return jpeg.Encode(file, dst, &jpeg.Options{Quality: 85})
+}
+
+// copyImageVariants 将本次生成用到的缩放图片从缓存复制到输出目录，variants 的键为相对站点根目录的路径，值为缓存文件路径
+func copyImageVariants(variants map[string]string, outputDir string) error {
+	for assetPath, cachePath := range variants {
+		dst := filepath.Join(outputDir, filepath.FromSlash(assetPath))
+		if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+			return err
+		}
+		if err := copyFile(cachePath, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// snippetDir 存放可以被文章包含的片段和源代码文件
+const snippetDir = "./data/snippets"
+
+// dependencyManifest 记录每个片段被哪些文章使用
+const dependencyManifest = "./data/cache/dependencies.json"
+
+// includePattern 匹配 {{< include "文件" lines="10-20" lang="go" >}}
+var includePattern = regexp.MustCompile(`\{\{<\s*include\s+"([^"]+)"((?:\s+\w+="[^"]*")*)\s*>\}\}`)
+
+// includeAttrPattern 匹配 include 指令中的属性
+var includeAttrPattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// includeEdit 记录正文中一条 include 指令被替换的位置和插入内容的行数
+type includeEdit struct {
+	offset, length int
+	newlines       int
+}
+
+// resolveIncludes 展开代码之外的 include 指令。Markdown 文件直接插入并继续展开其中的指令，
+// 其他文件放入代码块。返回展开后的内容、展开后每一行对应的原文行（从 0 开始）、用到的片段和遇到的问题
+func resolveIncludes(markdown string) (string, []int, []string, []string) {
+	deps := make(map[string]bool)
+	var problems []string
+	var edits []includeEdit
+	result := expandIncludes(markdown, nil, deps, &problems, &edits)
+
+	var files []string
+	for file := range deps {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return result, sourceLines(markdown, edits), files, problems
+}
+
+// sourceLines 根据替换记录计算展开后每一行对应的原文行，片段插入的行对应 include 指令所在的行
+func sourceLines(markdown string, edits []includeEdit) []int {
+	var lines []int
+	line, pos := 0, 0
+	advance := func(text string) {
+		for i := strings.Count(text, "\n"); i > 0; i-- {
+			lines = append(lines, line)
+			line++
+		}
+	}
+	for _, edit := range edits {
+		advance(markdown[pos:edit.offset])
+		for i := 0; i < edit.newlines; i++ {
+			lines = append(lines, line)
+		}
+		line += strings.Count(markdown[edit.offset:edit.offset+edit.length], "\n")
+		pos = edit.offset + edit.length
+	}
+	advance(markdown[pos:])
+	return append(lines, line)
+}
+
+// expandIncludes 递归展开 include 指令，stack 为当前正在展开的文件，用于检测循环包含。
+// edits 不为 nil 时记录每条指令的替换位置
+func expandIncludes(markdown string, stack []string, deps map[string]bool, problems *[]string, edits *[]includeEdit) string {
+	return transformOutsideCode(markdown, func(text string, offset int) string {
+		var builder strings.Builder
+		last := 0
+		for _, loc := range includePattern.FindAllStringIndex(text, -1) {
+			replacement := expandInclude(text[loc[0]:loc[1]], stack, deps, problems)
+			builder.WriteString(text[last:loc[0]])
+			builder.WriteString(replacement)
+			if edits != nil {
+				*edits = append(*edits, includeEdit{offset + loc[0], loc[1] - loc[0], strings.Count(replacement, "\n")})
+			}
+			last = loc[1]
+		}
+		builder.WriteString(text[last:])
+		return builder.String()
+	})
+}
+
+// expandInclude 返回一条 include 指令展开后的内容，无法展开时返回空字符串并记录问题
+func expandInclude(match string, stack []string, deps map[string]bool, problems *[]string) string {
+	parts := includePattern.FindStringSubmatch(match)
+	attrs := make(map[string]string)
+	for _, attr := range includeAttrPattern.FindAllStringSubmatch(parts[2], -1) {
+		attrs[attr[1]] = attr[2]
+	}
+
+	name, err := snippetName(parts[1])
+	if err != nil {
+		*problems = append(*problems, err.Error())
+		return ""
+	}
+	for i, file := range stack {
+		if file == name {
+			*problems = append(*problems, fmt.Sprintf("循环包含: %s -> %s", strings.Join(stack[i:], " -> "), name))
+			return ""
+		}
+	}
+	deps[name] = true
+
+	content, err := ioutil.ReadFile(filepath.Join(snippetDir, filepath.FromSlash(name)))
+	if err != nil {
+		*problems = append(*problems, fmt.Sprintf("无法读取包含的文件 %s: %v", name, err))
+		return ""
+	}
+	snippet := strings.ReplaceAll(string(content), "\r\n", "\n")
+
+	if lines, ok := attrs["lines"]; ok {
+		snippet, err = selectLines(snippet, lines)
+		if err != nil {
+			*problems = append(*problems, fmt.Sprintf("包含 %s: %v", name, err))
+			return ""
+		}
+	}
+
+	ext := strings.ToLower(path.Ext(name))
+	if ext == ".md" || ext == ".markdown" {
+		return expandIncludes(strings.TrimSuffix(snippet, "\n"), append(stack, name), deps, problems, nil)
+	}
+
+	lang, ok := attrs["lang"]
+	if !ok {
+		lang = strings.TrimPrefix(ext, ".")
+	}
+	return fencedCode(snippet, lang)
+}
+
+// snippetName 规范化包含的文件名，不允许引用 snippets 目录之外的文件
+func snippetName(name string) (string, error) {
+	cleaned, ok := relativeName(name)
+	if !ok {
+		return "", fmt.Errorf("包含的文件 %s 不在 snippets 目录中", name)
+	}
+	return cleaned, nil
+}
+
+// relativeName 规范化相对于数据目录的文件名，含有 .. 或以 / 开头的文件名返回 false
+func relativeName(name string) (string, bool) {
+	cleaned := path.Clean("/" + filepath.ToSlash(name))[1:]
+	if cleaned == "" || cleaned != strings.TrimPrefix(filepath.ToSlash(name), "./") {
+		return "", false
+	}
+	return cleaned, true
+}
+
+// selectLines 按 "10-20"、"10-"、"-20" 或 "10" 选取行，行号从 1 开始
+func selectLines(content, spec string) (string, error) {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	from, to := 1, len(lines)
+	var err error
+	if i := strings.Index(spec, "-"); i >= 0 {
+		if start := strings.TrimSpace(spec[:i]); start != "" {
+			if from, err = strconv.Atoi(start); err != nil {
+				return "", fmt.Errorf("无效的行范围 %s", spec)
+			}
+		}
+		if end := strings.TrimSpace(spec[i+1:]); end != "" {
+			if to, err = strconv.Atoi(end); err != nil {
+				return "", fmt.Errorf("无效的行范围 %s", spec)
+			}
+		}
+	} else {
+		if from, err = strconv.Atoi(strings.TrimSpace(spec)); err != nil {
+			return "", fmt.Errorf("无效的行范围 %s", spec)
+		}
+		to = from
+	}
+
+	if from < 1 || to > len(lines) || from > to {
+		return "", fmt.Errorf("行范围 %s 超出文件的 %d 行", spec, len(lines))
+	}
+	return strings.Join(lines[from-1:to], "\n") + "\n", nil
+}
+
+// fencedCode 将内容放入代码块，围栏比内容中最长的连续反引号更长
+func fencedCode(content, lang string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+			continue
+		}
+		run = 0
+	}
+	fence := strings.Repeat("`", 3)
+	if longest >= 3 {
+		fence = strings.Repeat("`", longest+1)
+	}
+	return "\n" + fence + lang + "\n" + content + fence + "\n"
+}
+
+// snippetUsers 返回每个片段被哪些文章文件使用，文件名按字母排序
+func snippetUsers(posts []PostMetadata) map[string][]string {
+	users := make(map[string][]string)
+	for _, post := range posts {
+		for _, file := range post.Dependencies {
+			users[file] = append(users[file], post.FileName)
+		}
+	}
+	for file := range users {
+		sort.Strings(users[file])
+	}
+	return users
+}
+
+// writeDependencyManifest 写入片段到使用它的文章文件的对应关系。
+// 上次生成后修改过的片段会在日志中列出因此重新生成的文章
+func writeDependencyManifest(posts []PostMetadata) error {
+	users := snippetUsers(posts)
+	if info, err := os.Stat(dependencyManifest); err == nil {
+		for _, file := range sortedKeys(users) {
+			snippet, err := os.Stat(filepath.Join(snippetDir, filepath.FromSlash(file)))
+			if err == nil && snippet.ModTime().After(info.ModTime()) {
+				log.Printf("片段 %s 已修改，重新生成使用它的文章: %s", file, strings.Join(users[file], ", "))
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dependencyManifest), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dependencyManifest, data, 0644)
+}
+
+// sortedKeys 返回按字母排序的片段文件名
+func sortedKeys(users map[string][]string) []string {
+	var files []string
+	for file := range users {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
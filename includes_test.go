@@ -0,0 +1,115 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectLines(t *testing.T) {
+	content := "one\ntwo\nthree\nfour\n"
+	tests := []struct {
+		spec string
+		want string
+		err  bool
+	}{
+		{spec: "2-3", want: "two\nthree\n"},
+		{spec: "3-", want: "three\nfour\n"},
+		{spec: "-2", want: "one\ntwo\n"},
+		{spec: "4", want: "four\n"},
+		{spec: " 1 - 1 ", want: "one\n"},
+		{spec: "0-2", err: true},
+		{spec: "3-5", err: true},
+		{spec: "3-2", err: true},
+		{spec: "a-b", err: true},
+		{spec: "x", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := selectLines(content, tt.spec)
+		if tt.err {
+			if err == nil {
+				t.Errorf("selectLines(%q) 应该出错，得到 %q", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("selectLines(%q) = %q, %v，期望 %q", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestSnippetName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		err  bool
+	}{
+		{name: "note.md", want: "note.md"},
+		{name: "./code/main.go", want: "code/main.go"},
+		{name: "../secret", err: true},
+		{name: "code/../../secret", err: true},
+		{name: "/etc/passwd", err: true},
+		{name: "", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := snippetName(tt.name)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("snippetName(%q) = %q, %v，期望 %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestSourceLines(t *testing.T) {
+	markdown := "a\n{{< include \"x.txt\" >}}\nb\nc"
+	offset := strings.Index(markdown, "{{<")
+	length := len(`{{< include "x.txt" >}}`)
+	tests := []struct {
+		name  string
+		edits []includeEdit
+		want  []int
+	}{
+		{name: "没有 include", want: []int{0, 1, 2, 3}},
+		{name: "插入 3 行", edits: []includeEdit{{offset, length, 3}}, want: []int{0, 1, 1, 1, 1, 2, 3}},
+		{name: "插入内容为空", edits: []includeEdit{{offset, length, 0}}, want: []int{0, 1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		if got := sourceLines(markdown, tt.edits); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: sourceLines = %v，期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFencedCode(t *testing.T) {
+	tests := []struct {
+		content string
+		lang    string
+		want    string
+	}{
+		{content: "x := 1\n", lang: "go", want: "\n```go\nx := 1\n```\n"},
+		{content: "```\ncode\n```\n", lang: "md", want: "\n````md\n```\ncode\n```\n````\n"},
+	}
+
+	for _, tt := range tests {
+		if got := fencedCode(tt.content, tt.lang); got != tt.want {
+			t.Errorf("fencedCode(%q) = %q，期望 %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestSnippetUsers(t *testing.T) {
+	posts := []PostMetadata{
+		{FileName: "b.md", Dependencies: []string{"note.md", "code/main.go"}},
+		{FileName: "a.md", Dependencies: []string{"note.md"}},
+		{FileName: "c.md"},
+	}
+	want := map[string][]string{
+		"note.md":      {"a.md", "b.md"},
+		"code/main.go": {"b.md"},
+	}
+	if got := snippetUsers(posts); !reflect.DeepEqual(got, want) {
+		t.Errorf("snippetUsers() = %v，期望 %v", got, want)
+	}
+}
@@ -13,7 +13,7 @@ import (
 
 func install() {
 	// 要检测和创建的目录
-	dirs := []string{"./data/config", "./data/public", "./data/templates"}
+	dirs := []string{"./data/config", "./data/public", "./data/templates", "./data/static", "./data/bibliography", "./data/snippets"}
 
 	// 检测并创建目录
 	for _, dir := range dirs {
@@ -35,9 +35,19 @@ func install() {
 
 	// 检测并创建 config 文件
 	checkAndCreateFile("./data/config/ftp.config", `{"server":"127.0.0.1","port":"21","username":"test","password":"test","push":false,"relpath":"/"}`)
+	checkAndCreateFile("./data/config/podcast.config", `{"enabled":false,"title":"","author":"","owner":"","email":"","artwork":"","category":"Technology","subcategory":"","explicit":false,"language":"zh-cn","type":"episodic"}`)
+	checkAndCreateFile("./data/config/robots.config", `{"rules":[{"user-agent":["*"],"allow":[],"disallow":[],"crawl-delay":0}]}`)
 	checkAndCreateFile("./data/config/github.config", `{"repository":"","branch":"main","token":"","push":false,"username":""}`)
 	checkAndCreateFile("./data/config/menu.config", `Frd:./friendlinks/
 Feed:./feed/`)
+	checkAndCreateFile("./data/config/authors.config", `authors:
+  roywang:
+    name: "ROYWANG"
+    bio: ""
+    avatar: ""
+    email: "admin@darm.bitaur.com"
+    links: []
+`)
 
 	// 检测并创建 .env 文件
 	checkAndCreateFile("./data/.env", `BLOG_AUTHOR="ROYWANG"
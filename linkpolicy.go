@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// 允许在站外链接上使用的 rel 值
+var allowedLinkRels = map[string]bool{
+	"nofollow":   true,
+	"noopener":   true,
+	"noreferrer": true,
+	"ugc":        true,
+	"sponsored":  true,
+}
+
+// 可信域名的链接不添加这些 rel 值
+var untrustedLinkRels = map[string]bool{
+	"nofollow":  true,
+	"ugc":       true,
+	"sponsored": true,
+}
+
+// linkAttributes 按站点的链接策略返回链接应使用的 target 和 rel，站内链接返回 internal 为 true
+func linkAttributes(href string, config *BlogConfig) (target string, rel []string, internal bool) {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", nil, true
+	}
+
+	// mailto:、tel: 等链接不做处理
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+		return "", nil, false
+	}
+	if u.Host == "" || u.Host == siteHost(config) {
+		return "", nil, true
+	}
+
+	trusted := isTrustedDomain(u.Hostname(), config.TrustedDomains)
+	for _, value := range config.ExternalLinkRel {
+		if trusted && untrustedLinkRels[value] {
+			continue
+		}
+		rel = append(rel, value)
+	}
+	return config.ExternalLinkTarget, rel, false
+}
+
+// applyLinkPolicy 为正文中的链接设置 target 和 rel，站内链接始终在当前标签页打开
+func applyLinkPolicy(doc *goquery.Document, config *BlogConfig) {
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		target, rel, internal := linkAttributes(href, config)
+		if internal {
+			s.RemoveAttr("target")
+			return
+		}
+		if target == "" && rel == nil {
+			return
+		}
+
+		if target != "" {
+			s.SetAttr("target", target)
+		}
+
+		// 保留作者已写的 rel 值
+		existing, _ := s.Attr("rel")
+		values := strings.Fields(existing)
+		for _, value := range rel {
+			if !containsString(values, value) {
+				values = append(values, value)
+			}
+		}
+		if len(values) > 0 {
+			s.SetAttr("rel", strings.Join(values, " "))
+		}
+	})
+}
+
+// parseLinkRel 解析配置中的 rel 值，忽略不支持的值
+func parseLinkRel(value string) []string {
+	var rel []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		item = strings.ToLower(item)
+		if allowedLinkRels[item] && !containsString(rel, item) {
+			rel = append(rel, item)
+		}
+	}
+	return rel
+}
+
+// isTrustedDomain 判断域名是否为可信域名或其子域名
+func isTrustedDomain(host string, trusted []string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range trusted {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// siteHost 返回博客地址中的主机名
+func siteHost(config *BlogConfig) string {
+	u, err := url.Parse(config.URI)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
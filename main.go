@@ -4,11 +4,17 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 )
 
 func main() {
 	install()
 
+	// 命令行执行 darm check 时只检查内容，存在错误时以非零状态码退出
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(checkCommand())
+	}
+
 	http.HandleFunc("/", indexHandler)
 	http.HandleFunc("/generate", generateHandler)
 	http.HandleFunc("/preview/", previewHandler)
@@ -21,7 +27,9 @@ func main() {
 	http.HandleFunc("/ftp", ftpHandler)
 	http.HandleFunc("/github", githubHandler)
 	http.HandleFunc("/edit", editHandler)
+	http.HandleFunc("/check", checkHandler)
 	http.HandleFunc("/res/", resHandler)
+	http.HandleFunc("/media/", mediaHandler)
 
 	http.HandleFunc("/delete-success", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintln(w, "文件删除成功。")
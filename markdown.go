@@ -3,18 +3,39 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"html"
 	"html/template"
 	"net/url"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/russross/blackfriday/v2"
+	nethtml "golang.org/x/net/html"
 )
 
 // convertMarkdownToHTML 使用 blackfriday 库将 Markdown 转换为 HTML 并添加特定格式的锚点
-func convertMarkdownToHTML(markdown string) string {
-	// 首先将 Markdown 转换为 HTML
-	output := blackfriday.Run([]byte(markdown))
+func convertMarkdownToHTML(markdown string, post *PostMetadata, config *BlogConfig, state *renderState) string {
+	// 公式先替换为占位符，避免其中的 _ 和 * 被当作强调处理
+	protected, formulas := protectMath(markdown)
+	rendered := make([]string, len(formulas))
+	for i, formula := range formulas {
+		var err error
+		if rendered[i], err = renderMath(formula); err != nil {
+			state.diagnostics = append(state.diagnostics, mathDiagnostic(post, markdown, formula, err))
+		}
+	}
+
+	// 将 Markdown 转换为 HTML
+	output := blackfriday.Run([]byte(protected))
+
+	// 未标记为可信的文章按白名单过滤 HTML，被移除的内容作为警告输出
+	if config.Sanitize && !post.Trusted {
+		sanitized, stripped := sanitizeHTML(string(output), config.SanitizeTags, config.SanitizeAttrs)
+		for _, message := range stripped {
+			state.diagnostics = append(state.diagnostics, Diagnostic{post.FileName, levelWarning, message})
+		}
+		output = []byte(sanitized)
+	}
 
 	// 解析 HTML
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(output))
@@ -23,18 +44,33 @@ func convertMarkdownToHTML(markdown string) string {
 		return ""
 	}
 
+	// 处理文献引用并在文末添加参考文献列表
+	processCitations(doc, post, config, state)
+
+	// 将占位符替换为 MathML，公式不经过 HTML 过滤
+	restoreMath(doc, rendered, formulas)
+
 	// 为每个 <h1> - <h6> 标签添加特定格式的锚点和链接
 	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(i int, s *goquery.Selection) {
 		// 提取标题文本并将其转换为 URL 编码，以便用作锚点
-		text := s.Text()
+		text, content := headingContent(s.Get(0))
 		encodedText := url.QueryEscape(text)
 
 		// 创建并设置锚点及链接，链接不包含文本
-		anchor := fmt.Sprintf(`<a style="padding:0px" href="#%s" target="_blank"></a>%s`, encodedText, text)
+		anchor := fmt.Sprintf(`<a style="padding:0px" href="#%s"></a>%s`, encodedText, content)
 		s.SetHtml(anchor) // 将标题内容设置为锚点链接加上原标题文本
 		s.SetAttr("id", encodedText)
 	})
 
+	// 处理 mermaid 和 dot 图表
+	processDiagrams(doc, post, config, state)
+
+	// 为本地图片生成响应式尺寸
+	processImages(doc, config, state)
+
+	// 按站点链接策略处理链接的 target 和 rel
+	applyLinkPolicy(doc, config)
+
 	// 输出修改后的 HTML
 	htmlString, err := doc.Html()
 	if err != nil {
@@ -57,6 +93,103 @@ func convertMarkdownToHTML(markdown string) string {
 	return bodyContent
 }
 
+// headingContent 返回标题的纯文本和用于显示的 HTML。公式保留 MathML，在纯文本中使用公式原文，
+// 其他标签只保留文字
+func headingContent(n *nethtml.Node) (string, string) {
+	var text, content strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case nethtml.TextNode:
+			text.WriteString(c.Data)
+			content.WriteString(html.EscapeString(c.Data))
+		case nethtml.ElementNode:
+			if c.Data == "math" || (c.Data == "code" && hasClass(c, "math-error")) {
+				// 无效的公式以 $...$ 原样显示
+				source := strings.Trim(nodeText(c), "$")
+				for _, attr := range c.Attr {
+					if attr.Key == "alttext" {
+						source = attr.Val
+					}
+				}
+				text.WriteString(source)
+				nethtml.Render(&content, c)
+				continue
+			}
+			childText, childContent := headingContent(c)
+			text.WriteString(childText)
+			content.WriteString(childContent)
+		}
+	}
+	return text.String(), content.String()
+}
+
+// hasClass 判断元素的 class 中是否包含 name
+func hasClass(n *nethtml.Node, name string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "class" {
+			for _, class := range strings.Fields(attr.Val) {
+				if class == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// nodeText 返回节点中的全部文字
+func nodeText(n *nethtml.Node) string {
+	if n.Type == nethtml.TextNode {
+		return n.Data
+	}
+	var builder strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		builder.WriteString(nodeText(c))
+	}
+	return builder.String()
+}
+
+// renderState 保存一次渲染过程中发现的问题和生成的缩放图片。每次生成或检查使用各自的 renderState，
+// 同时处理的请求之间不共享状态
+type renderState struct {
+	diagnostics   []Diagnostic
+	imageVariants map[string]string // 需要输出到站点的缩放图片，键为相对站点根目录的路径，值为缓存文件路径
+	checkOnly     bool              // 内容检查时不缩放图片、不写缓存、不执行图表命令
+}
+
+func newRenderState() *renderState {
+	return &renderState{imageVariants: make(map[string]string)}
+}
+
+// renderPosts 预先渲染所有文章的正文，生成摘要并计算反向链接，返回渲染过程中发现的问题和生成的缩放图片。
+// checkOnly 为 true 时只用于内容检查，渲染过程不产生任何文件
+func renderPosts(posts []PostMetadata, config *BlogConfig, checkOnly bool) *renderState {
+	state := newRenderState()
+	state.checkOnly = checkOnly
+	wikiIndex := buildWikiIndex(posts)
+
+	for i := range posts {
+		// 先展开 include 指令，片段中的 Wiki 链接和公式与正文一起处理
+		markdown, lines, deps, problems := resolveIncludes(posts[i].Content)
+		posts[i].SourceLines = lines
+		posts[i].Dependencies = deps
+		state.diagnostics = append(state.diagnostics, resolveEnclosure(&posts[i], config)...)
+		state.diagnostics = append(state.diagnostics, resolveCover(&posts[i], config, state)...)
+		for _, problem := range problems {
+			state.diagnostics = append(state.diagnostics, Diagnostic{posts[i].FileName, levelError, problem})
+		}
+
+		markdown, unresolved := resolveWikiLinks(markdown, wikiIndex, config)
+		state.diagnostics = append(state.diagnostics, wikiLinkDiagnostics(posts[i], unresolved)...)
+
+		posts[i].ContentHTML = convertMarkdownToHTML(markdown, &posts[i], config, state)
+		posts[i].Summary, posts[i].HasMore = splitSummary(posts[i].ContentHTML, config.SummaryLength)
+	}
+
+	computeBacklinks(posts, config)
+	return state
+}
+
 // safeHTML 是一个自定义模板函数，用来确保 HTML 内容不会被转义
 func safeHTML(html string) template.HTML {
 	return template.HTML(html)
@@ -0,0 +1,929 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	nethtml "golang.org/x/net/html"
+)
+
+// mathFormula 是从 Markdown 中取出的一个公式
+type mathFormula struct {
+	source  string
+	display bool // $$...$$ 为行间公式
+	offset  int  // 公式在 Markdown 中的字节位置
+}
+
+// mathPlaceholder 返回公式在 Markdown 转换期间的占位符，只包含字母和数字，不会被 blackfriday 处理
+func mathPlaceholder(i int) string {
+	return fmt.Sprintf("DARMMATH%dX", i)
+}
+
+// protectMath 将代码之外的 $...$ 和 $$...$$ 替换为占位符，\$ 表示普通的美元符号
+func protectMath(markdown string) (string, []mathFormula) {
+	var formulas []mathFormula
+
+	result := transformOutsideCode(markdown, func(text string, offset int) string {
+		var builder strings.Builder
+		for i := 0; i < len(text); {
+			switch {
+			case text[i] == '\\' && i+1 < len(text):
+				builder.WriteString(text[i : i+2])
+				i += 2
+				continue
+			case text[i] != '$':
+				builder.WriteByte(text[i])
+				i++
+				continue
+			}
+
+			if strings.HasPrefix(text[i:], "$$") {
+				end := strings.Index(text[i+2:], "$$")
+				if end >= 0 && strings.TrimSpace(text[i+2:i+2+end]) != "" {
+					formulas = append(formulas, mathFormula{source: strings.TrimSpace(text[i+2 : i+2+end]), display: true, offset: offset + i})
+					builder.WriteString(mathPlaceholder(len(formulas) - 1))
+					i += end + 4
+					continue
+				}
+				builder.WriteString("$$")
+				i += 2
+				continue
+			}
+
+			if end := inlineMathEnd(text, i); end > 0 {
+				formulas = append(formulas, mathFormula{source: text[i+1 : end], offset: offset + i})
+				builder.WriteString(mathPlaceholder(len(formulas) - 1))
+				i = end + 1
+				continue
+			}
+			builder.WriteByte('$')
+			i++
+		}
+		return builder.String()
+	})
+
+	return result, formulas
+}
+
+// inlineMathEnd 返回行内公式结束的 $ 的位置，开始的 $ 后和结束的 $ 前不能是空白，
+// 结束的 $ 后不能紧跟数字，以免把 "$5 和 $10" 这样的金额当作公式
+func inlineMathEnd(text string, start int) int {
+	if start+1 >= len(text) || strings.ContainsRune(" \t\n$", rune(text[start+1])) {
+		return -1
+	}
+	for j := start + 1; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case '\n':
+			// 公式不能跨越段落
+			rest := text[j+1:]
+			if k := strings.IndexByte(rest, '\n'); k >= 0 {
+				rest = rest[:k]
+			}
+			if strings.TrimSpace(rest) == "" {
+				return -1
+			}
+		case '$':
+			if strings.ContainsRune(" \t\n", rune(text[j-1])) {
+				continue
+			}
+			if j+1 < len(text) && text[j+1] >= '0' && text[j+1] <= '9' {
+				continue
+			}
+			return j
+		}
+	}
+	return -1
+}
+
+// renderMath 将公式转换为 MathML，无效的公式保留原文并返回错误
+func renderMath(formula mathFormula) (string, error) {
+	output, err := latexToMathML(formula.source, formula.display)
+	if err != nil {
+		delimiter := "$"
+		if formula.display {
+			delimiter = "$$"
+		}
+		return fmt.Sprintf(`<code class="math-error" title="%s">%s</code>`, html.EscapeString(err.Error()), html.EscapeString(delimiter+formula.source+delimiter)), err
+	}
+	return output, nil
+}
+
+// mathPlaceholderPattern 匹配公式占位符，分组为公式序号
+var mathPlaceholderPattern = regexp.MustCompile(`DARMMATH(\d+)X`)
+
+// restoreMath 将文本中的占位符替换为渲染后的公式，单独成段的行间公式去掉外层的 <p>。
+// 属性中不能放置 MathML，其中的占位符替换为公式原文，例如图片 alt 中的公式
+func restoreMath(doc *goquery.Document, rendered []string, formulas []mathFormula) {
+	if len(formulas) == 0 {
+		return
+	}
+	formula := func(match string) (int, bool) {
+		i, err := strconv.Atoi(mathPlaceholderPattern.FindStringSubmatch(match)[1])
+		return i, err == nil && i < len(formulas)
+	}
+
+	// 先收集文本节点并替换属性，再逐个替换文本节点
+	var textNodes []*nethtml.Node
+	collectMathText(doc.Nodes[0], &textNodes, func(value string) string {
+		return mathPlaceholderPattern.ReplaceAllStringFunc(value, func(match string) string {
+			if i, ok := formula(match); ok {
+				return formulas[i].source
+			}
+			return match
+		})
+	})
+
+	for _, node := range textNodes {
+		locs := mathPlaceholderPattern.FindAllStringIndex(node.Data, -1)
+		if len(locs) == 0 {
+			continue
+		}
+
+		// 单独成段的行间公式替换整个段落
+		parent := node.Parent
+		if len(locs) == 1 && parent.Data == "p" && parent.FirstChild == node && parent.LastChild == node &&
+			strings.TrimSpace(node.Data) == node.Data[locs[0][0]:locs[0][1]] {
+			if i, ok := formula(node.Data[locs[0][0]:locs[0][1]]); ok && formulas[i].display {
+				if replacement, err := parseMathFragment(rendered[i], formulas[i], parent.Parent); err == nil {
+					for _, n := range replacement {
+						parent.Parent.InsertBefore(n, parent)
+					}
+					parent.Parent.RemoveChild(parent)
+				}
+				continue
+			}
+		}
+
+		var replacement []*nethtml.Node
+		last := 0
+		for _, loc := range locs {
+			i, ok := formula(node.Data[loc[0]:loc[1]])
+			if !ok {
+				continue
+			}
+			nodes, err := parseMathFragment(rendered[i], formulas[i], parent)
+			if err != nil {
+				continue
+			}
+			if loc[0] > last {
+				replacement = append(replacement, &nethtml.Node{Type: nethtml.TextNode, Data: node.Data[last:loc[0]]})
+			}
+			replacement = append(replacement, nodes...)
+			last = loc[1]
+		}
+		if replacement == nil {
+			continue
+		}
+		if last < len(node.Data) {
+			replacement = append(replacement, &nethtml.Node{Type: nethtml.TextNode, Data: node.Data[last:]})
+		}
+		for _, n := range replacement {
+			parent.InsertBefore(n, node)
+		}
+		parent.RemoveChild(node)
+	}
+}
+
+// collectMathText 按文档顺序收集文本节点，并用 replaceAttr 替换所有属性值
+func collectMathText(n *nethtml.Node, nodes *[]*nethtml.Node, replaceAttr func(string) string) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case nethtml.TextNode:
+			*nodes = append(*nodes, c)
+		case nethtml.ElementNode:
+			for i := range c.Attr {
+				c.Attr[i].Val = replaceAttr(c.Attr[i].Val)
+			}
+			collectMathText(c, nodes, replaceAttr)
+		}
+	}
+}
+
+// parseMathFragment 解析渲染后的公式，并在 <math> 上记录公式原文，供标题锚点和辅助技术使用
+func parseMathFragment(rendered string, formula mathFormula, context *nethtml.Node) ([]*nethtml.Node, error) {
+	nodes, err := nethtml.ParseFragment(strings.NewReader(rendered), context)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range nodes {
+		if n.Type == nethtml.ElementNode && n.Data == "math" {
+			n.Attr = append(n.Attr, nethtml.Attribute{Key: "alttext", Val: formula.source})
+		}
+	}
+	return nodes, nil
+}
+
+// latexToMathML 将 LaTeX 公式的常用子集转换为 MathML
+func latexToMathML(source string, display bool) (string, error) {
+	p := &mathParser{src: []rune(source), display: display}
+
+	var rows []string
+	for {
+		row, term, err := p.parseRow()
+		if err != nil {
+			return "", err
+		}
+		rows = append(rows, row)
+		if term == "" {
+			break
+		}
+		if term != `\\` {
+			return "", unexpectedMathTerm(term)
+		}
+	}
+
+	body := rows[0]
+	if len(rows) > 1 {
+		body = "<mtable>"
+		for _, row := range rows {
+			body += "<mtr><mtd>" + row + "</mtd></mtr>"
+		}
+		body += "</mtable>"
+	}
+
+	mode := "inline"
+	if display {
+		mode = "block"
+	}
+	return fmt.Sprintf(`<math xmlns="http://www.w3.org/1998/Math/MathML" display="%s"><semantics><mrow>%s</mrow><annotation encoding="application/x-tex">%s</annotation></semantics></math>`,
+		mode, body, html.EscapeString(source)), nil
+}
+
+// unexpectedMathTerm 返回在错误位置结束解析时的错误
+func unexpectedMathTerm(term string) error {
+	switch term {
+	case "":
+		return errors.New("缺少 }")
+	case "}":
+		return errors.New("多余的 }")
+	case "&":
+		return errors.New("& 只能在矩阵或对齐环境中使用")
+	case `\\`:
+		return errors.New(`\\ 只能在公式顶层或环境中使用`)
+	case "end":
+		return errors.New(`\end 缺少对应的 \begin`)
+	case "right":
+		return errors.New(`\right 缺少对应的 \left`)
+	}
+	return fmt.Errorf("无法解析 %s", term)
+}
+
+// mathNode 是解析出的一个元素，movable 表示在行间公式中上下标放在正下方和正上方
+type mathNode struct {
+	xml     string
+	movable bool
+}
+
+type mathParser struct {
+	src     []rune
+	pos     int
+	display bool
+}
+
+func (p *mathParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *mathParser) peek() rune {
+	return p.src[p.pos]
+}
+
+func (p *mathParser) skipSpaces() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// parseRow 解析一串元素，直到公式结束或遇到 }、&、\\、\end、\right，并返回遇到的结束标记
+func (p *mathParser) parseRow() (string, string, error) {
+	var nodes []mathNode
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return joinMathNodes(nodes), "", nil
+		}
+
+		if c := p.peek(); c == '^' || c == '_' {
+			base := mathNode{xml: "<mrow></mrow>"}
+			if n := len(nodes); n > 0 {
+				base, nodes = nodes[n-1], nodes[:n-1]
+			}
+			node, err := p.parseScripts(base)
+			if err != nil {
+				return "", "", err
+			}
+			nodes = append(nodes, node)
+			continue
+		}
+
+		node, term, err := p.parseAtom()
+		if err != nil {
+			return "", "", err
+		}
+		if term != "" {
+			return joinMathNodes(nodes), term, nil
+		}
+		if node.xml != "" {
+			nodes = append(nodes, node)
+		}
+	}
+}
+
+func joinMathNodes(nodes []mathNode) string {
+	var builder strings.Builder
+	for _, node := range nodes {
+		builder.WriteString(node.xml)
+	}
+	return builder.String()
+}
+
+// parseScripts 解析 base 之后的上标和下标
+func (p *mathParser) parseScripts(base mathNode) (mathNode, error) {
+	var sub, sup string
+	hasSub, hasSup := false, false
+	for !p.eof() && (p.peek() == '^' || p.peek() == '_') {
+		c := p.peek()
+		p.pos++
+		arg, err := p.parseArgument()
+		if err != nil {
+			return mathNode{}, err
+		}
+		if c == '^' {
+			if hasSup {
+				return mathNode{}, errors.New("重复的上标")
+			}
+			sup, hasSup = arg, true
+		} else {
+			if hasSub {
+				return mathNode{}, errors.New("重复的下标")
+			}
+			sub, hasSub = arg, true
+		}
+		p.skipSpaces()
+	}
+
+	under, over, both := "msub", "msup", "msubsup"
+	if base.movable && p.display {
+		under, over, both = "munder", "mover", "munderover"
+	}
+	switch {
+	case hasSub && hasSup:
+		return mathNode{xml: fmt.Sprintf("<%s>%s%s%s</%s>", both, base.xml, sub, sup, both)}, nil
+	case hasSub:
+		return mathNode{xml: fmt.Sprintf("<%s>%s%s</%s>", under, base.xml, sub, under)}, nil
+	default:
+		return mathNode{xml: fmt.Sprintf("<%s>%s%s</%s>", over, base.xml, sup, over)}, nil
+	}
+}
+
+// parseArgument 解析命令或上下标的参数，可以是 {...} 或单个元素
+func (p *mathParser) parseArgument() (string, error) {
+	p.skipSpaces()
+	if p.eof() {
+		return "", errors.New("缺少参数")
+	}
+	if p.peek() == '{' {
+		p.pos++
+		row, term, err := p.parseRow()
+		if err != nil {
+			return "", err
+		}
+		if term != "}" {
+			return "", unexpectedMathTerm(term)
+		}
+		return "<mrow>" + row + "</mrow>", nil
+	}
+
+	node, term, err := p.parseAtom()
+	if err != nil {
+		return "", err
+	}
+	if term != "" || node.xml == "" {
+		return "", errors.New("缺少参数")
+	}
+	return node.xml, nil
+}
+
+// parseAtom 解析一个元素，遇到结束标记时返回该标记
+func (p *mathParser) parseAtom() (mathNode, string, error) {
+	c := p.peek()
+	p.pos++
+
+	switch {
+	case c == '{':
+		row, term, err := p.parseRow()
+		if err != nil {
+			return mathNode{}, "", err
+		}
+		if term != "}" {
+			return mathNode{}, "", unexpectedMathTerm(term)
+		}
+		return mathNode{xml: "<mrow>" + row + "</mrow>"}, "", nil
+	case c == '}':
+		return mathNode{}, "}", nil
+	case c == '&':
+		return mathNode{}, "&", nil
+	case c == '\\':
+		return p.parseCommand()
+	case c == '\'':
+		primes := "′"
+		for !p.eof() && p.peek() == '\'' {
+			primes += "′"
+			p.pos++
+		}
+		return mathNode{xml: "<mo>" + primes + "</mo>"}, "", nil
+	case c == '~':
+		return mathNode{xml: `<mspace width="0.2778em"/>`}, "", nil
+	case unicode.IsDigit(c) || (c == '.' && !p.eof() && unicode.IsDigit(p.peek())):
+		start := p.pos - 1
+		for !p.eof() && (unicode.IsDigit(p.peek()) || p.peek() == '.') {
+			p.pos++
+		}
+		return mathNode{xml: "<mn>" + string(p.src[start:p.pos]) + "</mn>"}, "", nil
+	case unicode.IsLetter(c):
+		return mathNode{xml: "<mi>" + html.EscapeString(string(c)) + "</mi>"}, "", nil
+	}
+
+	if replacement, ok := mathCharOperators[c]; ok {
+		return mathNode{xml: "<mo>" + replacement + "</mo>"}, "", nil
+	}
+	return mathNode{xml: "<mo>" + html.EscapeString(string(c)) + "</mo>"}, "", nil
+}
+
+// readCommandName 读取 \ 之后的命令名，命令名为一串字母或单个其他字符
+func (p *mathParser) readCommandName() (string, error) {
+	if p.eof() {
+		return "", errors.New(`公式以 \ 结尾`)
+	}
+	start := p.pos
+	if !isASCIILetter(p.peek()) {
+		p.pos++
+		return string(p.src[start:p.pos]), nil
+	}
+	for !p.eof() && isASCIILetter(p.peek()) {
+		p.pos++
+	}
+	return string(p.src[start:p.pos]), nil
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// readGroupText 读取 {...} 中的原始文本
+func (p *mathParser) readGroupText() (string, error) {
+	p.skipSpaces()
+	if p.eof() || p.peek() != '{' {
+		return "", errors.New("缺少 {")
+	}
+	p.pos++
+	start, depth := p.pos, 0
+	for ; !p.eof(); p.pos++ {
+		switch p.peek() {
+		case '\\':
+			p.pos++
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				text := string(p.src[start:p.pos])
+				p.pos++
+				return text, nil
+			}
+			depth--
+		}
+	}
+	return "", errors.New("缺少 }")
+}
+
+// parseCommand 解析以 \ 开头的命令
+func (p *mathParser) parseCommand() (mathNode, string, error) {
+	name, err := p.readCommandName()
+	if err != nil {
+		return mathNode{}, "", err
+	}
+
+	switch name {
+	case `\`:
+		return mathNode{}, `\\`, nil
+	case "end":
+		return mathNode{}, "end", nil
+	case "right":
+		return mathNode{}, "right", nil
+	case "left":
+		node, err := p.parseLeftRight()
+		return node, "", err
+	case "begin":
+		node, err := p.parseEnvironment()
+		return node, "", err
+	case "frac", "dfrac", "tfrac", "cfrac", "binom":
+		num, err := p.parseArgument()
+		if err != nil {
+			return mathNode{}, "", fmt.Errorf(`\%s 缺少分子: %v`, name, err)
+		}
+		den, err := p.parseArgument()
+		if err != nil {
+			return mathNode{}, "", fmt.Errorf(`\%s 缺少分母: %v`, name, err)
+		}
+		if name == "binom" {
+			return mathNode{xml: `<mrow><mo>(</mo><mfrac linethickness="0">` + num + den + `</mfrac><mo>)</mo></mrow>`}, "", nil
+		}
+		return mathNode{xml: "<mfrac>" + num + den + "</mfrac>"}, "", nil
+	case "sqrt":
+		node, err := p.parseSqrt()
+		return node, "", err
+	case "text", "textrm", "textit", "textbf", "mbox", "operatorname":
+		text, err := p.readGroupText()
+		if err != nil {
+			return mathNode{}, "", fmt.Errorf(`\%s %v`, name, err)
+		}
+		if name == "operatorname" {
+			return mathNode{xml: "<mi>" + html.EscapeString(text) + "</mi>"}, "", nil
+		}
+		return mathNode{xml: "<mtext>" + html.EscapeString(text) + "</mtext>"}, "", nil
+	case "displaystyle", "textstyle", "limits", "nolimits", "nonumber", "notag":
+		return mathNode{}, "", nil
+	case "big", "Big", "bigg", "Bigg", "bigl", "bigr", "Bigl", "Bigr", "biggl", "biggr":
+		delimiter, err := p.readDelimiter()
+		if err != nil {
+			return mathNode{}, "", err
+		}
+		return mathNode{xml: `<mo stretchy="false">` + delimiter + "</mo>"}, "", nil
+	}
+
+	if variant, ok := mathVariants[name]; ok {
+		arg, err := p.parseArgument()
+		if err != nil {
+			return mathNode{}, "", fmt.Errorf(`\%s %v`, name, err)
+		}
+		arg = strings.ReplaceAll(arg, "<mi>", `<mi mathvariant="`+variant+`">`)
+		if variant == "bold" {
+			arg = strings.ReplaceAll(arg, "<mn>", `<mn mathvariant="bold">`)
+		}
+		return mathNode{xml: arg}, "", nil
+	}
+	if accent, ok := mathAccents[name]; ok {
+		arg, err := p.parseArgument()
+		if err != nil {
+			return mathNode{}, "", fmt.Errorf(`\%s %v`, name, err)
+		}
+		if name == "underline" || name == "underbrace" {
+			return mathNode{xml: `<munder accentunder="true">` + arg + `<mo stretchy="true">` + accent + "</mo></munder>"}, "", nil
+		}
+		return mathNode{xml: `<mover accent="true">` + arg + `<mo stretchy="true">` + accent + "</mo></mover>"}, "", nil
+	}
+	if width, ok := mathSpaces[name]; ok {
+		return mathNode{xml: `<mspace width="` + width + `"/>`}, "", nil
+	}
+	if symbol, ok := mathIdentifiers[name]; ok {
+		return mathNode{xml: "<mi>" + symbol + "</mi>"}, "", nil
+	}
+	if symbol, ok := mathOperators[name]; ok {
+		return mathNode{xml: "<mo>" + html.EscapeString(symbol) + "</mo>"}, "", nil
+	}
+	if symbol, ok := mathLargeOperators[name]; ok {
+		// 积分号的上下限始终放在右侧
+		movable := !strings.Contains(name, "int")
+		return mathNode{xml: `<mo largeop="true">` + symbol + "</mo>", movable: movable}, "", nil
+	}
+	if movable, ok := mathFunctions[name]; ok {
+		return mathNode{xml: "<mi>" + name + "</mi>", movable: movable}, "", nil
+	}
+	return mathNode{}, "", fmt.Errorf(`不支持的命令 \%s`, name)
+}
+
+// parseSqrt 解析 \sqrt{x} 和 \sqrt[n]{x}
+func (p *mathParser) parseSqrt() (mathNode, error) {
+	p.skipSpaces()
+	index := ""
+	if !p.eof() && p.peek() == '[' {
+		end, depth := -1, 0
+		for i := p.pos + 1; i < len(p.src) && end < 0; i++ {
+			switch p.src[i] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			case ']':
+				if depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			return mathNode{}, errors.New(`\sqrt 的次数缺少 ]`)
+		}
+		sub := &mathParser{src: p.src[p.pos+1 : end], display: p.display}
+		row, term, err := sub.parseRow()
+		if err != nil {
+			return mathNode{}, err
+		}
+		if term != "" {
+			return mathNode{}, unexpectedMathTerm(term)
+		}
+		index = "<mrow>" + row + "</mrow>"
+		p.pos = end + 1
+	}
+
+	arg, err := p.parseArgument()
+	if err != nil {
+		return mathNode{}, fmt.Errorf(`\sqrt %v`, err)
+	}
+	if index != "" {
+		return mathNode{xml: "<mroot>" + arg + index + "</mroot>"}, nil
+	}
+	return mathNode{xml: "<msqrt>" + arg + "</msqrt>"}, nil
+}
+
+// readDelimiter 读取 \left、\right 和 \big 之后的定界符，"." 表示不显示定界符
+func (p *mathParser) readDelimiter() (string, error) {
+	p.skipSpaces()
+	if p.eof() {
+		return "", errors.New("缺少定界符")
+	}
+	c := p.peek()
+	p.pos++
+	if c == '\\' {
+		name, err := p.readCommandName()
+		if err != nil {
+			return "", err
+		}
+		if delimiter, ok := mathDelimiters[name]; ok {
+			return delimiter, nil
+		}
+		return "", fmt.Errorf(`\%s 不是有效的定界符`, name)
+	}
+	if c == '.' {
+		return "", nil
+	}
+	if strings.ContainsRune("()[]|/<>", c) {
+		return html.EscapeString(string(c)), nil
+	}
+	return "", fmt.Errorf("%c 不是有效的定界符", c)
+}
+
+// parseLeftRight 解析 \left ... \right
+func (p *mathParser) parseLeftRight() (mathNode, error) {
+	left, err := p.readDelimiter()
+	if err != nil {
+		return mathNode{}, fmt.Errorf(`\left %v`, err)
+	}
+	row, term, err := p.parseRow()
+	if err != nil {
+		return mathNode{}, err
+	}
+	if term != "right" {
+		if term == "" {
+			return mathNode{}, errors.New(`\left 缺少对应的 \right`)
+		}
+		return mathNode{}, unexpectedMathTerm(term)
+	}
+	right, err := p.readDelimiter()
+	if err != nil {
+		return mathNode{}, fmt.Errorf(`\right %v`, err)
+	}
+
+	fence := func(delimiter string) string {
+		if delimiter == "" {
+			return ""
+		}
+		return `<mo fence="true" stretchy="true">` + delimiter + "</mo>"
+	}
+	return mathNode{xml: "<mrow>" + fence(left) + row + fence(right) + "</mrow>"}, nil
+}
+
+// parseEnvironment 解析 \begin{...} ... \end{...} 环境
+func (p *mathParser) parseEnvironment() (mathNode, error) {
+	name, err := p.readGroupText()
+	if err != nil {
+		return mathNode{}, fmt.Errorf(`\begin %v`, err)
+	}
+	env, ok := mathEnvironments[name]
+	if !ok {
+		return mathNode{}, fmt.Errorf("不支持的环境 %s", name)
+	}
+	if name == "array" {
+		// 忽略列格式说明
+		if _, err := p.readGroupText(); err != nil {
+			return mathNode{}, fmt.Errorf(`\begin{array} 缺少列格式: %v`, err)
+		}
+	}
+
+	var rows [][]string
+	var cells []string
+	for {
+		cell, term, err := p.parseRow()
+		if err != nil {
+			return mathNode{}, err
+		}
+		cells = append(cells, cell)
+
+		switch term {
+		case "&":
+			continue
+		case `\\`:
+			rows = append(rows, cells)
+			cells = nil
+			continue
+		case "end":
+			endName, err := p.readGroupText()
+			if err != nil {
+				return mathNode{}, fmt.Errorf(`\end %v`, err)
+			}
+			if endName != name {
+				return mathNode{}, fmt.Errorf(`\begin{%s} 与 \end{%s} 不匹配`, name, endName)
+			}
+		case "":
+			return mathNode{}, fmt.Errorf(`\begin{%s} 缺少 \end{%s}`, name, name)
+		default:
+			return mathNode{}, unexpectedMathTerm(term)
+		}
+		break
+	}
+	// 最后一行之后的 \\ 不产生空行
+	if len(cells) > 1 || cells[0] != "" || len(rows) == 0 {
+		rows = append(rows, cells)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("<mrow>")
+	if env.left != "" {
+		builder.WriteString(`<mo fence="true" stretchy="true">` + env.left + "</mo>")
+	}
+	builder.WriteString("<mtable")
+	if env.align != "" {
+		builder.WriteString(` columnalign="` + env.align + `"`)
+	}
+	builder.WriteString(">")
+	for _, row := range rows {
+		builder.WriteString("<mtr>")
+		for _, cell := range row {
+			builder.WriteString("<mtd>" + cell + "</mtd>")
+		}
+		builder.WriteString("</mtr>")
+	}
+	builder.WriteString("</mtable>")
+	if env.right != "" {
+		builder.WriteString(`<mo fence="true" stretchy="true">` + env.right + "</mo>")
+	}
+	builder.WriteString("</mrow>")
+	return mathNode{xml: builder.String()}, nil
+}
+
+// mathEnvironment 描述环境两侧的定界符和列对齐方式
+type mathEnvironment struct {
+	left, right, align string
+}
+
+var mathEnvironments = map[string]mathEnvironment{
+	"matrix":   {},
+	"array":    {},
+	"pmatrix":  {"(", ")", ""},
+	"bmatrix":  {"[", "]", ""},
+	"Bmatrix":  {"{", "}", ""},
+	"vmatrix":  {"|", "|", ""},
+	"Vmatrix":  {"‖", "‖", ""},
+	"cases":    {"{", "", "left left"},
+	"aligned":  {"", "", "right left"},
+	"align":    {"", "", "right left"},
+	"align*":   {"", "", "right left"},
+	"gathered": {},
+}
+
+// 直接输入时需要替换显示的运算符
+var mathCharOperators = map[rune]string{
+	'-': "−",
+	'*': "∗",
+	'<': "&lt;",
+	'>': "&gt;",
+}
+
+// mathVariants 为字体命令对应的 mathvariant
+var mathVariants = map[string]string{
+	"mathbf":     "bold",
+	"boldsymbol": "bold",
+	"mathit":     "italic",
+	"mathrm":     "normal",
+	"mathbb":     "double-struck",
+	"mathcal":    "script",
+	"mathscr":    "script",
+	"mathfrak":   "fraktur",
+	"mathsf":     "sans-serif",
+	"mathtt":     "monospace",
+}
+
+var mathAccents = map[string]string{
+	"hat":        "^",
+	"widehat":    "^",
+	"bar":        "¯",
+	"overline":   "¯",
+	"vec":        "→",
+	"tilde":      "~",
+	"widetilde":  "~",
+	"dot":        "˙",
+	"ddot":       "¨",
+	"overbrace":  "⏞",
+	"underline":  "_",
+	"underbrace": "⏟",
+}
+
+var mathSpaces = map[string]string{
+	",":     "0.1667em",
+	":":     "0.2222em",
+	">":     "0.2222em",
+	";":     "0.2778em",
+	" ":     "0.2778em",
+	"!":     "-0.1667em",
+	"quad":  "1em",
+	"qquad": "2em",
+}
+
+var mathDelimiters = map[string]string{
+	"{":      "{",
+	"}":      "}",
+	"|":      "‖",
+	"lbrace": "{",
+	"rbrace": "}",
+	"langle": "⟨",
+	"rangle": "⟩",
+	"lvert":  "|",
+	"rvert":  "|",
+	"vert":   "|",
+	"Vert":   "‖",
+	"lVert":  "‖",
+	"rVert":  "‖",
+	"lfloor": "⌊",
+	"rfloor": "⌋",
+	"lceil":  "⌈",
+	"rceil":  "⌉",
+}
+
+// mathIdentifiers 为希腊字母和其他作为标识符的符号
+var mathIdentifiers = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "varpi": "ϖ", "rho": "ρ",
+	"varrho": "ϱ", "sigma": "σ", "varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ϕ",
+	"varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	"infty": "∞", "partial": "∂", "nabla": "∇", "emptyset": "∅", "varnothing": "∅",
+	"ell": "ℓ", "hbar": "ℏ", "Re": "ℜ", "Im": "ℑ", "aleph": "ℵ", "imath": "ı", "jmath": "ȷ",
+	"$": "$", "#": "#", "%": "%", "_": "_",
+}
+
+// mathOperators 为运算符、关系符、箭头和括号
+var mathOperators = map[string]string{
+	"+": "+", "{": "{", "}": "}", "&": "&", "|": "‖",
+	"cdot": "⋅", "times": "×", "div": "÷", "pm": "±", "mp": "∓", "ast": "∗", "star": "⋆",
+	"circ": "∘", "bullet": "∙", "oplus": "⊕", "ominus": "⊖", "otimes": "⊗", "odot": "⊙",
+	"setminus": "∖", "wedge": "∧", "land": "∧", "vee": "∨", "lor": "∨", "neg": "¬", "lnot": "¬",
+	"cup": "∪", "cap": "∩", "sqcup": "⊔", "sqcap": "⊓",
+	"le": "≤", "leq": "≤", "ge": "≥", "geq": "≥", "ne": "≠", "neq": "≠", "ll": "≪", "gg": "≫",
+	"approx": "≈", "equiv": "≡", "sim": "∼", "simeq": "≃", "cong": "≅", "propto": "∝",
+	"prec": "≺", "succ": "≻", "preceq": "⪯", "succeq": "⪰", "doteq": "≐",
+	"in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "supset": "⊃", "subseteq": "⊆",
+	"supseteq": "⊇", "perp": "⊥", "parallel": "∥", "mid": "∣", "nmid": "∤",
+	"forall": "∀", "exists": "∃", "nexists": "∄", "therefore": "∴", "because": "∵",
+	"to": "→", "gets": "←", "rightarrow": "→", "leftarrow": "←", "leftrightarrow": "↔",
+	"Rightarrow": "⇒", "Leftarrow": "⇐", "Leftrightarrow": "⇔", "implies": "⟹", "iff": "⟺",
+	"mapsto": "↦", "longrightarrow": "⟶", "longleftarrow": "⟵", "uparrow": "↑", "downarrow": "↓",
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉",
+	"ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+	"angle": "∠", "triangle": "△", "prime": "′",
+}
+
+// mathLargeOperators 为求和、求积和积分等大型运算符
+var mathLargeOperators = map[string]string{
+	"sum": "∑", "prod": "∏", "coprod": "∐", "int": "∫", "iint": "∬", "iiint": "∭", "oint": "∮",
+	"bigcup": "⋃", "bigcap": "⋂", "bigoplus": "⨁", "bigotimes": "⨂", "bigvee": "⋁", "bigwedge": "⋀",
+}
+
+// mathFunctions 为函数名，值表示行间公式中下标是否放在正下方
+var mathFunctions = map[string]bool{
+	"sin": false, "cos": false, "tan": false, "cot": false, "sec": false, "csc": false,
+	"arcsin": false, "arccos": false, "arctan": false, "sinh": false, "cosh": false, "tanh": false,
+	"log": false, "ln": false, "lg": false, "exp": false, "arg": false, "dim": false,
+	"ker": false, "hom": false, "deg": false,
+	"lim": true, "limsup": true, "liminf": true, "max": true, "min": true, "sup": true,
+	"inf": true, "det": true, "gcd": true, "Pr": true, "argmax": true, "argmin": true,
+}
+
+// mathDiagnostic 返回无效公式的诊断信息，包含公式在文章文件中的行号
+func mathDiagnostic(post *PostMetadata, markdown string, formula mathFormula, err error) Diagnostic {
+	// 展开 include 后的行号需要换算为文章中的行号，片段中的公式定位到 include 指令所在的行
+	line := strings.Count(markdown[:formula.offset], "\n")
+	if line < len(post.SourceLines) {
+		line = post.SourceLines[line]
+	}
+	line += post.ContentLine
+	return Diagnostic{post.FileName, levelError, fmt.Sprintf("第 %d 行的公式 %s 无效: %v", line, formula.source, err)}
+}
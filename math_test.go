@@ -0,0 +1,148 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLatexToMathML(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string // 期望出现在 <mrow> 中的 MathML
+		err    string // 期望的错误信息，为空时不应出错
+	}{
+		{source: `x^2`, want: `<msup><mi>x</mi><mn>2</mn></msup>`},
+		{source: `a_{i}`, want: `<msub><mi>a</mi><mrow><mi>i</mi></mrow></msub>`},
+		{source: `\frac{a}{b}`, want: `<mfrac><mrow><mi>a</mi></mrow><mrow><mi>b</mi></mrow></mfrac>`},
+		{source: `\sqrt{x}`, want: `<msqrt><mrow><mi>x</mi></mrow></msqrt>`},
+		{source: `\alpha+\beta`, want: `<mi>α</mi><mo>+</mo><mi>β</mi>`},
+		{source: `12.5`, want: `<mn>12.5</mn>`},
+		{source: `\left( x \right)`, want: `<mrow><mo fence="true" stretchy="true">(</mo><mi>x</mi><mo fence="true" stretchy="true">)</mo></mrow>`},
+		{source: `a \\ b`, want: `<mtable><mtr><mtd><mi>a</mi></mtd></mtr><mtr><mtd><mi>b</mi></mtd></mtr></mtable>`},
+		{source: `\begin{pmatrix}a&b\\c&d\end{pmatrix}`, want: `<mtr><mtd><mi>a</mi></mtd><mtd><mi>b</mi></mtd></mtr><mtr><mtd><mi>c</mi></mtd><mtd><mi>d</mi></mtd></mtr>`},
+		{source: `x^`, err: "缺少参数"},
+		{source: `\frac{a}`, err: "缺少分母"},
+		{source: `\unknown`, err: `不支持的命令 \unknown`},
+	}
+
+	for _, tt := range tests {
+		got, err := latexToMathML(tt.source, false)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("latexToMathML(%q) 错误为 %v，期望包含 %q", tt.source, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("latexToMathML(%q) 出错: %v", tt.source, err)
+			continue
+		}
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("latexToMathML(%q) = %s，期望包含 %s", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestLatexToMathMLDisplay(t *testing.T) {
+	got, err := latexToMathML(`a<b`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, `display="block"`) {
+		t.Errorf("行间公式应为 block: %s", got)
+	}
+	if !strings.Contains(got, `<annotation encoding="application/x-tex">a&lt;b</annotation>`) {
+		t.Errorf("公式原文应转义后放入 annotation: %s", got)
+	}
+}
+
+func TestProtectMath(t *testing.T) {
+	tests := []struct {
+		markdown string
+		want     string
+		formulas []string
+	}{
+		{markdown: "质能 $E=mc^2$ 方程", want: "质能 DARMMATH0X 方程", formulas: []string{"E=mc^2"}},
+		{markdown: "$$\n\\sum_i i\n$$\n", want: "DARMMATH0X\n", formulas: []string{`\sum_i i`}},
+		{markdown: `价格 \$5 和 \$6`, want: `价格 \$5 和 \$6`},
+		{markdown: "代码 `$x$` 不处理", want: "代码 `$x$` 不处理"},
+		{markdown: "```\n$x$\n```\n$y$", want: "```\n$x$\n```\nDARMMATH0X", formulas: []string{"y"}},
+	}
+
+	for _, tt := range tests {
+		got, formulas := protectMath(tt.markdown)
+		if got != tt.want {
+			t.Errorf("protectMath(%q) = %q，期望 %q", tt.markdown, got, tt.want)
+		}
+		if len(formulas) != len(tt.formulas) {
+			t.Errorf("protectMath(%q) 得到 %d 个公式，期望 %d 个", tt.markdown, len(formulas), len(tt.formulas))
+			continue
+		}
+		for i, formula := range formulas {
+			if formula.source != tt.formulas[i] {
+				t.Errorf("protectMath(%q) 第 %d 个公式为 %q，期望 %q", tt.markdown, i, formula.source, tt.formulas[i])
+			}
+		}
+	}
+}
+
+func TestConvertMarkdownMath(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []string
+		unwanted []string
+	}{
+		{
+			name:     "标题中的公式",
+			markdown: "## 能量 $E=mc^2$\n",
+			want:     []string{`id="%E8%83%BD%E9%87%8F+E%3Dmc%5E2"`, `href="#%E8%83%BD%E9%87%8F+E%3Dmc%5E2"`, `alttext="E=mc^2"`},
+			unwanted: []string{"DARMMATH"},
+		},
+		{
+			name:     "图片说明中的公式",
+			markdown: "![面积 $\\pi r^2$](https://example.com/a.png)\n",
+			want:     []string{`alt="面积 \pi r^2"`},
+			unwanted: []string{"DARMMATH", "<math"},
+		},
+		{
+			name:     "单独成段的行间公式",
+			markdown: "$$\nx^2\n$$\n",
+			want:     []string{`<math xmlns="http://www.w3.org/1998/Math/MathML" display="block"`},
+			unwanted: []string{"<p>"},
+		},
+		{
+			name:     "无效的公式",
+			markdown: "错误 $x^$ 公式\n",
+			want:     []string{`<code class="math-error"`, `$x^$`},
+		},
+	}
+
+	for _, tt := range tests {
+		post := &PostMetadata{FileName: "test.md", ContentLine: 1}
+		state := newRenderState()
+		state.checkOnly = true
+		got := convertMarkdownToHTML(tt.markdown, post, &BlogConfig{}, state)
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: 输出中没有 %s:\n%s", tt.name, want, got)
+			}
+		}
+		for _, unwanted := range tt.unwanted {
+			if strings.Contains(got, unwanted) {
+				t.Errorf("%s: 输出中不应有 %s:\n%s", tt.name, unwanted, got)
+			}
+		}
+	}
+}
+
+func TestMathDiagnosticLine(t *testing.T) {
+	// 第 2 行的 include 展开为 3 行，第 4 行的公式在展开后位于第 6 行
+	post := &PostMetadata{FileName: "test.md", ContentLine: 10, SourceLines: []int{0, 1, 1, 1, 2, 3}}
+	markdown := "a\nx\ny\nz\nb\n$x^$"
+	formula := mathFormula{source: "x^", offset: strings.Index(markdown, "$")}
+	d := mathDiagnostic(post, markdown, formula, nil)
+	if !strings.Contains(d.Message, "第 13 行") {
+		t.Errorf("诊断信息 %q 应定位到第 13 行", d.Message)
+	}
+}
@@ -9,8 +9,8 @@ import (
 	"strings"
 )
 
-// ReadMenuConfig 读取并解析 menu.config 文件
-func ReadMenuConfig(filePath string) template.HTML {
+// ReadMenuConfig 读取并解析 menu.config 文件，菜单链接同样遵循站点的链接策略
+func ReadMenuConfig(filePath string, config *BlogConfig) template.HTML {
 	file, err := os.Open(filePath)
 	if err != nil {
 		log.Fatalf("打开文件失败: %v", err)
@@ -23,7 +23,15 @@ func ReadMenuConfig(filePath string) template.HTML {
 		line := scanner.Text()
 		parts := strings.SplitN(line, ":", 2)
 		if len(parts) == 2 {
-			menuItem := fmt.Sprintf("<li><a target=\"_blank\" rel=\"noopener\" href=\"%s\">%s</a></li>", parts[1], parts[0])
+			attrs := ""
+			target, rel, _ := linkAttributes(parts[1], config)
+			if target != "" {
+				attrs += fmt.Sprintf(" target=\"%s\"", target)
+			}
+			if len(rel) > 0 {
+				attrs += fmt.Sprintf(" rel=\"%s\"", strings.Join(rel, " "))
+			}
+			menuItem := fmt.Sprintf("<li><a%s href=\"%s\">%s</a></li>", attrs, parts[1], parts[0])
 			menuItems = append(menuItems, menuItem)
 		}
 	}
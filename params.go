@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// knownFrontMatterKeys 保存 PostMetadata 已经解析的头部字段名
+var knownFrontMatterKeys = frontMatterKeys(reflect.TypeOf(PostMetadata{}))
+
+// frontMatterKeys 按 yaml.v2 的规则计算结构体字段对应的键名
+func frontMatterKeys(t reflect.Type) map[string]bool {
+	keys := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		keys[name] = true
+	}
+	return keys
+}
+
+// extractParams 从文章头部中提取 PostMetadata 未定义的字段，供模板通过 .Params 使用
+func extractParams(frontMatter string) map[string]interface{} {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal([]byte(frontMatter), &raw); err != nil {
+		return nil
+	}
+
+	params := make(map[string]interface{})
+	for key, value := range raw {
+		if knownFrontMatterKeys[key] {
+			continue
+		}
+		params[key] = normalizeParam(value)
+	}
+	return params
+}
+
+// normalizeParam 将 yaml.v2 解析出的 map[interface{}]interface{} 递归转换为 map[string]interface{}
+func normalizeParam(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalizeParam(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeParam(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		frontMatter string
+		want        map[string]interface{}
+	}{
+		{
+			name:        "只有已定义的字段",
+			frontMatter: "title: \"标题\"\ntags: [a, b]\ncitation-style: numeric\n",
+			want:        map[string]interface{}{},
+		},
+		{
+			name:        "未定义的标量字段",
+			frontMatter: "title: \"标题\"\nsubtitle: 副标题\ndraft: true\nweight: 3\n",
+			want:        map[string]interface{}{"subtitle": "副标题", "draft": true, "weight": 3},
+		},
+		{
+			name:        "嵌套的映射和列表",
+			frontMatter: "series:\n  name: Go\n  parts:\n    - id: 1\n",
+			want: map[string]interface{}{
+				"series": map[string]interface{}{
+					"name":  "Go",
+					"parts": []interface{}{map[string]interface{}{"id": 1}},
+				},
+			},
+		},
+		{
+			name:        "非字符串的键",
+			frontMatter: "scores:\n  1: one\n  true: two\n",
+			want:        map[string]interface{}{"scores": map[string]interface{}{"1": "one", "true": "two"}},
+		},
+		{
+			name:        "格式错误",
+			frontMatter: "title: [\n",
+			want:        nil,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := extractParams(tt.frontMatter); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: extractParams = %#v，期望 %#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFrontMatterKeys(t *testing.T) {
+	for _, key := range []string{"title", "uri", "content", "citation-style", "noindex"} {
+		if !knownFrontMatterKeys[key] {
+			t.Errorf("%s 应为已定义的头部字段", key)
+		}
+	}
+	for _, key := range []string{"filename", "contenthtml", "sourcelines", "-"} {
+		if knownFrontMatterKeys[key] {
+			t.Errorf("%s 不应为头部字段", key)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// PodcastConfig 对应 podcast.config，启用后 RSS 订阅会输出 iTunes 播客标签
+type PodcastConfig struct {
+	Enabled     bool   `json:"enabled"`
+	Title       string `json:"title"`  // 播客名称，留空时使用博客标题
+	Author      string `json:"author"` // 留空时使用博客作者
+	Owner       string `json:"owner"`
+	Email       string `json:"email"`
+	Artwork     string `json:"artwork"` // 封面图片，可以是站内路径或完整地址
+	Category    string `json:"category"`
+	Subcategory string `json:"subcategory"`
+	Explicit    bool   `json:"explicit"`
+	Language    string `json:"language"`
+	Type        string `json:"type"` // episodic 或 serial
+}
+
+// Enclosure 是文章头部 enclosure 字段声明的音频或视频附件
+type Enclosure struct {
+	File     string `yaml:"file"`     // 站内路径或完整地址
+	Length   int64  `yaml:"length"`   // 文件字节数，站内文件可以省略
+	Type     string `yaml:"type"`     // MIME 类型，省略时按扩展名判断
+	Duration string `yaml:"duration"` // 时长，格式为 HH:MM:SS、MM:SS 或秒数
+
+	URL string `yaml:"-"` // 附件的完整地址
+}
+
+// IsVideo 判断附件是否为视频
+func (e *Enclosure) IsVideo() bool {
+	return strings.HasPrefix(e.Type, "video/")
+}
+
+// LoadPodcastConfig 读取播客配置，文件不存在时返回未启用的配置
+func LoadPodcastConfig(filePath string) (*PodcastConfig, error) {
+	config := &PodcastConfig{}
+
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("解析播客配置失败: %v", err)
+	}
+	return config, nil
+}
+
+// resolveEnclosure 补全文章附件的地址、类型和大小，返回发现的问题
+func resolveEnclosure(post *PostMetadata, config *BlogConfig) []Diagnostic {
+	enclosure := post.Enclosure
+	if enclosure == nil {
+		return nil
+	}
+
+	var diagnostics []Diagnostic
+	if enclosure.File == "" {
+		return []Diagnostic{{post.FileName, levelError, "enclosure 缺少 file"}}
+	}
+
+	u, err := url.Parse(enclosure.File)
+	if err == nil && u.IsAbs() {
+		enclosure.URL = enclosure.File
+	} else {
+		assetPath := strings.TrimPrefix(path.Clean("/"+enclosure.File), "/")
+		enclosure.URL = config.URI + "/" + (&url.URL{Path: assetPath}).EscapedPath()
+
+		info, err := os.Stat(localAssetPath(assetPath))
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{post.FileName, levelError, fmt.Sprintf("附件 %s 不存在", enclosure.File)})
+		} else if enclosure.Length == 0 {
+			enclosure.Length = info.Size()
+		}
+	}
+
+	if enclosure.Type == "" {
+		if mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(path.Ext(enclosure.File))); err == nil {
+			enclosure.Type = mediaType
+		}
+	}
+	if enclosure.Type == "" {
+		diagnostics = append(diagnostics, Diagnostic{post.FileName, levelWarning, fmt.Sprintf("无法确定附件 %s 的类型，请在 enclosure 中设置 type", enclosure.File)})
+	}
+	if enclosure.Duration != "" {
+		if _, ok := durationSeconds(enclosure.Duration); !ok {
+			diagnostics = append(diagnostics, Diagnostic{post.FileName, levelWarning, fmt.Sprintf("附件时长 %s 的格式应为 HH:MM:SS、MM:SS 或秒数", enclosure.Duration)})
+		}
+	}
+	return diagnostics
+}
+
+// durationSeconds 将 HH:MM:SS、MM:SS 或秒数格式的时长转换为秒
+func durationSeconds(value string) (int, bool) {
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+	seconds := 0
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || (i > 0 && n >= 60) {
+			return 0, false
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds, true
+}
+
+// podcastArtwork 返回播客封面的完整地址
+func podcastArtwork(podcast *PodcastConfig, config *BlogConfig) string {
+	if podcast.Artwork == "" {
+		return ""
+	}
+	if u, err := url.Parse(podcast.Artwork); err == nil && u.IsAbs() {
+		return podcast.Artwork
+	}
+	return config.URI + "/" + strings.TrimPrefix(podcast.Artwork, "/")
+}
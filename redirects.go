@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// redirectTemplate 是旧地址上的跳转页，同时给出 canonical 链接
+var redirectTemplate = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+    <meta charset="UTF-8">
+    <title>{{.Title}}</title>
+    <link rel="canonical" href="{{.Target}}">
+    <meta name="robots" content="noindex">
+    <meta http-equiv="refresh" content="0; url={{.Target}}">
+</head>
+<body>
+    <p>页面已迁移至 <a href="{{.Target}}">{{.Target}}</a></p>
+</body>
+</html>
+`))
+
+// generateRedirects 为文章的 aliases 生成跳转页，并按配置生成 _redirects 和 nginx 规则文件
+func generateRedirects(posts []PostMetadata, config *BlogConfig, outputDir string) error {
+	postURIs := make(map[string]bool)
+	for _, post := range posts {
+		postURIs[strings.Trim(post.URI, "/")] = true
+	}
+
+	basePath := ""
+	if u, err := url.Parse(config.URI); err == nil {
+		basePath = strings.TrimSuffix(u.Path, "/")
+	}
+
+	var netlifyRules, nginxRules []string
+	for _, post := range posts {
+		target := config.URI + "/" + post.URI + "/"
+		for _, alias := range post.Aliases {
+			alias, ok := cleanAlias(alias)
+			if !ok {
+				log.Printf("文章 %s 的别名 %s 不是站内路径，已跳过", post.URI, alias)
+				continue
+			}
+			if alias == "" || alias == strings.Trim(post.URI, "/") {
+				continue
+			}
+			if postURIs[alias] {
+				log.Printf("文章 %s 的别名 %s 与其他文章的 URI 冲突，已跳过", post.URI, alias)
+				continue
+			}
+
+			aliasPath := filepath.Join(outputDir, filepath.FromSlash(alias), "index.html")
+			if err := os.MkdirAll(filepath.Dir(aliasPath), os.ModePerm); err != nil {
+				return fmt.Errorf("创建目录失败: %v", err)
+			}
+			file, err := os.Create(aliasPath)
+			if err != nil {
+				return fmt.Errorf("创建文件失败: %v", err)
+			}
+			err = redirectTemplate.Execute(file, map[string]string{
+				"Title":  post.Title,
+				"Target": target,
+			})
+			file.Close()
+			if err != nil {
+				return fmt.Errorf("写入跳转页失败: %v", err)
+			}
+
+			from := basePath + "/" + alias + "/"
+			to := basePath + "/" + strings.Trim(post.URI, "/") + "/"
+			netlifyRules = append(netlifyRules, from+" "+to+" 301")
+			nginxRules = append(nginxRules, "rewrite ^"+regexp.QuoteMeta(strings.TrimSuffix(from, "/"))+"/?$ "+to+" permanent;")
+		}
+	}
+
+	if config.RedirectsFile && len(netlifyRules) > 0 {
+		content := strings.Join(netlifyRules, "\n") + "\n"
+		if err := ioutil.WriteFile(filepath.Join(outputDir, "_redirects"), []byte(content), 0644); err != nil {
+			return fmt.Errorf("写入 _redirects 失败: %v", err)
+		}
+	}
+	if config.NginxRedirects && len(nginxRules) > 0 {
+		content := strings.Join(nginxRules, "\n") + "\n"
+		if err := ioutil.WriteFile(filepath.Join(outputDir, "redirects.nginx.conf"), []byte(content), 0644); err != nil {
+			return fmt.Errorf("写入 nginx 跳转规则失败: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// cleanAlias 去掉别名两端的 /，含有 .. 或 \\ 等无法作为站内路径的别名返回 false
+func cleanAlias(alias string) (string, bool) {
+	trimmed := strings.Trim(alias, "/")
+	if trimmed == "" {
+		return "", true
+	}
+	if strings.Contains(trimmed, "\\") || path.Clean("/" + trimmed)[1:] != trimmed {
+		return alias, false
+	}
+	return trimmed, true
+}
+
+// frontMatterURI 读取文章头部中的 uri 字段
+func frontMatterURI(content string) string {
+	sections := strings.SplitN(content, "---", 3)
+	if len(sections) < 3 {
+		return ""
+	}
+
+	var metadata PostMetadata
+	if err := yaml.Unmarshal([]byte(sections[1]), &metadata); err != nil {
+		return ""
+	}
+	return metadata.URI
+}
+
+// recordURIAlias 在文章 uri 改变时，将旧 uri 写入新内容头部的 aliases 中。
+// 只修改 aliases 一行或在列表末尾追加一项，头部的其他内容保持原样
+func recordURIAlias(oldContent, newContent string) string {
+	oldURI := strings.Trim(frontMatterURI(oldContent), "/")
+	newURI := strings.Trim(frontMatterURI(newContent), "/")
+	if oldURI == "" || newURI == "" || oldURI == newURI {
+		return newContent
+	}
+
+	sections := strings.SplitN(newContent, "---", 3)
+	var metadata PostMetadata
+	if err := yaml.Unmarshal([]byte(sections[1]), &metadata); err != nil {
+		return newContent
+	}
+	for _, alias := range metadata.Aliases {
+		if strings.Trim(alias, "/") == oldURI {
+			return newContent
+		}
+	}
+
+	frontMatter, ok := appendAlias(sections[1], strconv.Quote(oldURI))
+	if !ok {
+		log.Printf("无法在 aliases 中记录旧 uri %s，请手动添加", oldURI)
+		return newContent
+	}
+	return sections[0] + "---" + frontMatter + "---" + sections[2]
+}
+
+// appendAlias 在头部文本的 aliases 中追加一项，支持 [a, b] 和 - a 两种写法，没有 aliases 时在末尾添加一行
+func appendAlias(frontMatter, alias string) (string, bool) {
+	lines := strings.SplitAfter(frontMatter, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "aliases:") {
+			continue
+		}
+		ending := line[len(strings.TrimRight(line, "\r\n")):]
+		value := strings.TrimSpace(strings.TrimPrefix(line, "aliases:"))
+
+		switch {
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			if inner := strings.TrimSpace(value[1 : len(value)-1]); inner == "" {
+				value = "[" + alias + "]"
+			} else {
+				value = value[:len(value)-1] + ", " + alias + "]"
+			}
+			lines[i] = "aliases: " + value + ending
+
+		case value == "" || value == "~" || value == "null":
+			// 找到列表的最后一项，新项使用相同的缩进
+			last, indent := i, "  "
+			for j := i + 1; j < len(lines); j++ {
+				item := strings.TrimLeft(lines[j], " \t")
+				if !strings.HasPrefix(item, "-") {
+					break
+				}
+				last, indent = j, lines[j][:len(lines[j])-len(item)]
+			}
+			if last == i {
+				lines[i] = "aliases: [" + alias + "]" + ending
+				break
+			}
+			item := indent + "- " + alias + "\n"
+			lines = append(lines[:last+1], append([]string{item}, lines[last+1:]...)...)
+
+		default:
+			return frontMatter, false
+		}
+		return strings.Join(lines, ""), true
+	}
+
+	if !strings.HasSuffix(frontMatter, "\n") {
+		frontMatter += "\n"
+	}
+	return frontMatter + "aliases: [" + alias + "]\n", true
+}
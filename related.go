@@ -0,0 +1,97 @@
+package main
+
+import (
+	"sort"
+)
+
+// PostNeighbors 保存一篇文章的相邻文章及相关文章
+type PostNeighbors struct {
+	PrevPost           *PostMetadata // 发布时间更早的上一篇
+	NextPost           *PostMetadata // 发布时间更晚的下一篇
+	PrevPostInCategory *PostMetadata // 同分类中的上一篇
+	NextPostInCategory *PostMetadata // 同分类中的下一篇
+	RelatedPosts       []PostMetadata
+}
+
+// findPostNeighbors 计算 posts[index] 的相邻文章和相关文章，posts 需已按日期由近到远排序
+func findPostNeighbors(posts []PostMetadata, index int, relatedCount int) PostNeighbors {
+	var neighbors PostNeighbors
+	current := posts[index]
+
+	if index+1 < len(posts) {
+		neighbors.PrevPost = &posts[index+1]
+	}
+	if index > 0 {
+		neighbors.NextPost = &posts[index-1]
+	}
+
+	// 同分类中向前、向后查找最近的文章
+	for i := index + 1; i < len(posts); i++ {
+		if posts[i].Category == current.Category {
+			neighbors.PrevPostInCategory = &posts[i]
+			break
+		}
+	}
+	for i := index - 1; i >= 0; i-- {
+		if posts[i].Category == current.Category {
+			neighbors.NextPostInCategory = &posts[i]
+			break
+		}
+	}
+
+	neighbors.RelatedPosts = findRelatedPosts(posts, index, relatedCount)
+	return neighbors
+}
+
+// findRelatedPosts 按共同标签数和是否同分类为文章打分，返回得分最高的 count 篇文章
+func findRelatedPosts(posts []PostMetadata, index int, count int) []PostMetadata {
+	if count <= 0 {
+		return nil
+	}
+
+	current := posts[index]
+	currentTags := make(map[string]bool)
+	for _, tag := range current.Tags {
+		currentTags[tag] = true
+	}
+
+	type scoredPost struct {
+		index int
+		score int
+	}
+	var candidates []scoredPost
+	for i, post := range posts {
+		if i == index {
+			continue
+		}
+
+		// 每个共同标签计 2 分，同分类计 1 分
+		score := 0
+		for _, tag := range post.Tags {
+			if currentTags[tag] {
+				score += 2
+			}
+		}
+		if current.Category != "" && post.Category == current.Category {
+			score++
+		}
+		if score > 0 {
+			candidates = append(candidates, scoredPost{index: i, score: score})
+		}
+	}
+
+	// 得分相同时保持原有顺序，即较新的文章在前
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+
+	related := make([]PostMetadata, 0, len(candidates))
+	for _, candidate := range candidates {
+		related = append(related, posts[candidate.index])
+	}
+	return related
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// RobotsConfig 对应 robots.config，描述 robots.txt 中的规则
+type RobotsConfig struct {
+	Rules []RobotsRule `json:"rules"`
+}
+
+// RobotsRule 是一组爬虫共用的规则
+type RobotsRule struct {
+	UserAgents []string `json:"user-agent"` // 为空时适用于所有爬虫
+	Allow      []string `json:"allow"`
+	Disallow   []string `json:"disallow"`    // 为空时允许抓取全部页面
+	CrawlDelay float64  `json:"crawl-delay"` // 抓取间隔秒数，0 表示不限制
+}
+
+// LoadRobotsConfig 读取 robots 规则，文件不存在时返回允许所有爬虫的规则
+func LoadRobotsConfig(filePath string) (*RobotsConfig, error) {
+	config := &RobotsConfig{}
+
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("解析 robots 配置失败: %v", err)
+	}
+
+	if len(config.Rules) == 0 {
+		config.Rules = []RobotsRule{{}}
+	}
+	return config, nil
+}
+
+// generateRobotsTxt 按 robots 配置生成 robots.txt，并指向站点地图
+func generateRobotsTxt(posts []PostMetadata, blogconfigs *BlogConfig, outputPath string) error {
+	var b strings.Builder
+	for i, rule := range blogconfigs.Robots.Rules {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		agents := rule.UserAgents
+		if len(agents) == 0 {
+			agents = []string{"*"}
+		}
+		for _, agent := range agents {
+			b.WriteString("User-agent: " + agent + "\n")
+		}
+		for _, path := range rule.Allow {
+			b.WriteString("Allow: " + path + "\n")
+		}
+		if len(rule.Disallow) == 0 {
+			b.WriteString("Disallow:\n")
+		}
+		for _, path := range rule.Disallow {
+			b.WriteString("Disallow: " + path + "\n")
+		}
+		if rule.CrawlDelay > 0 {
+			b.WriteString("Crawl-delay: " + strconv.FormatFloat(rule.CrawlDelay, 'f', -1, 64) + "\n")
+		}
+	}
+	b.WriteString("\nSitemap: " + blogconfigs.URI + "/sitemap.xml\n")
+
+	// 创建并写入文件
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(b.String())
+	if err != nil {
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+
+	return nil
+}
+
+// postRobots 返回文章页面 robots meta 标签的内容，允许收录时返回空字符串
+func postRobots(post PostMetadata) string {
+	if post.NoIndex {
+		return "noindex"
+	}
+	return ""
+}
+
+// pageRobots 返回列表和搜索页面 robots meta 标签的内容。页面类型在 NOINDEX_PAGES 中，
+// 或列出的文章全部设置了 noindex 时不允许收录
+func pageRobots(config *BlogConfig, pageType string, posts []PostMetadata) string {
+	if noIndexPage(config, pageType) {
+		return "noindex"
+	}
+	for _, post := range posts {
+		if !post.NoIndex {
+			return ""
+		}
+	}
+	if len(posts) > 0 {
+		return "noindex"
+	}
+	return ""
+}
+
+// noIndexPage 判断页面类型是否在 NOINDEX_PAGES 中
+func noIndexPage(config *BlogConfig, pageType string) bool {
+	for _, t := range config.NoIndexPages {
+		if t == pageType {
+			return true
+		}
+	}
+	return false
+}
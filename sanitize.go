@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// 默认允许的标签和属性
+var (
+	defaultSanitizeTags = []string{
+		"a", "abbr", "b", "blockquote", "br", "caption", "cite", "code", "col", "colgroup",
+		"dd", "del", "details", "div", "dl", "dt", "em", "figcaption", "figure",
+		"h1", "h2", "h3", "h4", "h5", "h6", "hr", "i", "img", "ins", "kbd", "li", "mark",
+		"ol", "p", "pre", "q", "s", "small", "span", "strong", "sub", "summary", "sup",
+		"table", "tbody", "td", "tfoot", "th", "thead", "tr", "u", "ul",
+	}
+	defaultSanitizeAttrs = []string{
+		"href", "src", "alt", "title", "class", "id", "width", "height", "align",
+		"colspan", "rowspan", "start", "cite", "datetime", "lang", "dir", "open",
+	}
+)
+
+// 这些标签连同其内容一起移除
+var dropWithContent = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"noscript": true, "template": true, "frame": true, "frameset": true, "applet": true,
+}
+
+// 包含地址的属性，需要检查协议
+var urlAttrs = map[string]bool{"href": true, "src": true, "cite": true}
+
+// 地址属性允许的协议
+var allowedURLSchemes = map[string]bool{"": true, "http": true, "https": true, "mailto": true}
+
+// sanitizeHTML 按白名单过滤 HTML，返回过滤后的内容以及被移除内容的说明
+func sanitizeHTML(source string, allowedTags, allowedAttrs []string) (string, []string) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(source), context)
+	if err != nil {
+		return "", []string{fmt.Sprintf("解析 HTML 失败: %v", err)}
+	}
+
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, node := range nodes {
+		root.AppendChild(node)
+	}
+
+	tags := make(map[string]bool)
+	for _, tag := range allowedTags {
+		tags[tag] = true
+	}
+	attrs := make(map[string]bool)
+	for _, attr := range allowedAttrs {
+		attrs[attr] = true
+	}
+
+	report := &sanitizeReport{counts: make(map[string]int)}
+	sanitizeNode(root, tags, attrs, report)
+
+	var builder strings.Builder
+	for node := root.FirstChild; node != nil; node = node.NextSibling {
+		if err := html.Render(&builder, node); err != nil {
+			return "", []string{fmt.Sprintf("生成 HTML 失败: %v", err)}
+		}
+	}
+	return builder.String(), report.messages()
+}
+
+// sanitizeReport 按出现顺序统计被移除的内容
+type sanitizeReport struct {
+	order  []string
+	counts map[string]int
+}
+
+func (r *sanitizeReport) add(message string) {
+	if r.counts[message] == 0 {
+		r.order = append(r.order, message)
+	}
+	r.counts[message]++
+}
+
+func (r *sanitizeReport) messages() []string {
+	var messages []string
+	for _, message := range r.order {
+		if count := r.counts[message]; count > 1 {
+			message = fmt.Sprintf("%s (%d 处)", message, count)
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// sanitizeNode 递归过滤子节点，不允许的标签会被移除或展开，不允许的属性会被删除
+func sanitizeNode(n *html.Node, tags, attrs map[string]bool, report *sanitizeReport) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		if c.Type != html.ElementNode {
+			c = next
+			continue
+		}
+
+		if dropWithContent[c.Data] {
+			report.add(fmt.Sprintf("已移除 <%s> 标签及其内容", c.Data))
+			n.RemoveChild(c)
+			c = next
+			continue
+		}
+
+		if !tags[c.Data] {
+			// 展开标签，保留其中的内容继续过滤
+			report.add(fmt.Sprintf("已移除 <%s> 标签", c.Data))
+			first := c.FirstChild
+			for child := c.FirstChild; child != nil; {
+				following := child.NextSibling
+				c.RemoveChild(child)
+				n.InsertBefore(child, c)
+				child = following
+			}
+			n.RemoveChild(c)
+			if first != nil {
+				next = first
+			}
+			c = next
+			continue
+		}
+
+		kept := c.Attr[:0]
+		for _, attr := range c.Attr {
+			name := strings.ToLower(attr.Key)
+			if attr.Namespace != "" || !attrs[name] || strings.HasPrefix(name, "on") {
+				report.add(fmt.Sprintf("已移除 <%s> 的 %s 属性", c.Data, attr.Key))
+				continue
+			}
+			if urlAttrs[name] && !isSafeURL(attr.Val) {
+				report.add(fmt.Sprintf("已移除 <%s> 的不安全地址 %s", c.Data, attr.Val))
+				continue
+			}
+			kept = append(kept, attr)
+		}
+		c.Attr = kept
+
+		sanitizeNode(c, tags, attrs, report)
+		c = next
+	}
+}
+
+// isSafeURL 判断地址的协议是否在允许范围内
+func isSafeURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	return allowedURLSchemes[strings.ToLower(u.Scheme)]
+}
@@ -19,7 +19,21 @@ func GenerateSearchPage(allTags *TagsData, blogConfig *BlogConfig, posts []PostM
 		log.Fatalf("创建index.txt失败: %v", err)
 	}
 
+	// 只出现在 noindex 文章中的标签不进入搜索索引
+	indexed := make(map[string]bool)
+	for _, post := range posts {
+		if post.NoIndex {
+			continue
+		}
+		for _, tag := range post.Tags {
+			indexed[tag] = true
+		}
+	}
+
 	for _, tag := range allTags.AllTag {
+		if !indexed[tag] {
+			continue
+		}
 		_, err := file.WriteString(tag + "\n")
 		if err != nil {
 			log.Printf("向index.txt写入标签失败: %v", err)
@@ -35,6 +49,9 @@ func GenerateSearchPage(allTags *TagsData, blogConfig *BlogConfig, posts []PostM
 		"BlogURI":         blogConfig.URI,
 		"BlogTags":        blogConfig.Tags,
 		"BlogAuthor":      blogConfig.Author,
+		"FeedLinks":       siteFeedLinks(blogConfig),
+		"SEO":             pageSEO(blogConfig, "Search - "+blogConfig.Title, blogConfig.Description, blogConfig.URI+"/search/"),
+		"Robots":          pageRobots(blogConfig, "search", nil),
 		"PageType":        "search",
 	}
 
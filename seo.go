@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+)
+
+// SEO 是页面头部的规范地址、Open Graph、Twitter 卡片和 JSON-LD 数据，由 header.html 直接输出
+type SEO struct {
+	Canonical     string
+	Title         string
+	Description   string
+	Image         string
+	Type          string // website 或 article
+	SiteName      string
+	PublishedTime string
+	Author        string
+	Tags          []string
+	TwitterCard   string // 有图片时为 summary_large_image
+	TwitterSite   string
+	JSONLD        template.JS
+}
+
+// pageURL 返回列表页面第 page 页的地址
+func pageURL(base string, page int) string {
+	if page <= 1 {
+		return base
+	}
+	return fmt.Sprintf("%spage/%d/", base, page)
+}
+
+// pageSEO 返回首页、标签、分类、作者和搜索等列表页面的 SEO 数据，首页使用 WebSite，其他页面使用 WebPage
+func pageSEO(config *BlogConfig, title, description, canonical string) *SEO {
+	seo := &SEO{
+		Canonical:   canonical,
+		Title:       title,
+		Description: description,
+		Image:       config.SEOImage,
+		Type:        "website",
+		SiteName:    config.Title,
+		TwitterSite: config.TwitterSite,
+	}
+	seo.TwitterCard = twitterCard(seo.Image)
+
+	website := map[string]interface{}{
+		"@type":       "WebSite",
+		"name":        config.Title,
+		"url":         config.URI + "/",
+		"description": config.Description,
+	}
+	if canonical == config.URI+"/" {
+		website["@context"] = "https://schema.org"
+		seo.JSONLD = jsonLD(website)
+		return seo
+	}
+
+	seo.JSONLD = jsonLD(map[string]interface{}{
+		"@context":    "https://schema.org",
+		"@type":       "WebPage",
+		"name":        title,
+		"url":         canonical,
+		"description": description,
+		"isPartOf":    website,
+	})
+	return seo
+}
+
+// postSEO 返回文章页面的 SEO 数据，JSON-LD 使用 BlogPosting
+func postSEO(post PostMetadata, config *BlogConfig) *SEO {
+	postURL := config.URI + "/" + post.URI + "/"
+	description := post.Description
+	if description == "" {
+		description = plainText(post.Summary)
+	}
+
+	seo := &SEO{
+		Canonical:     postURL,
+		Title:         post.Title,
+		Description:   description,
+		Image:         config.SEOImage,
+		Type:          "article",
+		SiteName:      config.Title,
+		PublishedTime: post.Date,
+		Author:        postFeedAuthor(post, config).Name,
+		Tags:          post.Tags,
+		TwitterSite:   config.TwitterSite,
+	}
+	// 优先使用生成成功的分享卡片，其次是封面和正文中的第一张图片
+	if post.ShareCard != "" {
+		seo.Image = post.ShareCard
+	} else if post.CoverLarge != "" {
+		seo.Image = post.CoverLarge
+	} else if images := postImages(post, postURL); len(images) > 0 {
+		seo.Image = images[0].Loc
+	}
+	seo.TwitterCard = twitterCard(seo.Image)
+
+	posting := map[string]interface{}{
+		"@context":         "https://schema.org",
+		"@type":            "BlogPosting",
+		"headline":         post.Title,
+		"description":      description,
+		"url":              postURL,
+		"mainEntityOfPage": postURL,
+		"author": map[string]interface{}{
+			"@type": "Person",
+			"name":  seo.Author,
+		},
+		"publisher": map[string]interface{}{
+			"@type": "Organization",
+			"name":  config.Title,
+			"url":   config.URI + "/",
+		},
+	}
+	if post.Date != "" {
+		posting["datePublished"] = post.Date
+	}
+	if post.CoverLarge != "" {
+		posting["image"] = post.CoverLarge
+	} else if seo.Image != "" {
+		posting["image"] = seo.Image
+	}
+	if len(post.Tags) > 0 {
+		posting["keywords"] = post.Tags
+	}
+	if post.WordCount > 0 {
+		posting["wordCount"] = post.WordCount
+	}
+	seo.JSONLD = jsonLD(posting)
+	return seo
+}
+
+// twitterCard 根据是否有图片选择 Twitter 卡片类型
+func twitterCard(image string) string {
+	if image != "" {
+		return "summary_large_image"
+	}
+	return "summary"
+}
+
+// jsonLD 将结构化数据编码为可以放入 script 标签的 JSON，< > & 会被转义
+func jsonLD(data map[string]interface{}) template.JS {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("生成 JSON-LD 失败: %v", err)
+		return ""
+	}
+	return template.JS(encoded)
+}
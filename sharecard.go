@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// 分享卡片的尺寸，与 Open Graph 推荐的 1.91:1 一致
+const (
+	shareCardWidth   = 1200
+	shareCardHeight  = 630
+	shareCardPadding = 80
+	shareCardCover   = 420 // 封面图片在右侧占据的宽度
+)
+
+// shareCardFonts 缓存已解析的字体，键为字体文件路径，空字符串为内置字体。
+// 多个生成请求可能同时读写，访问时需要持有 shareCardFontsMu
+var (
+	shareCardFonts   = make(map[string]*sfnt.Font)
+	shareCardFontsMu sync.Mutex
+)
+
+// shareCardURL 返回文章分享卡片的地址
+func shareCardURL(post PostMetadata, config *BlogConfig) string {
+	return config.URI + "/" + post.URI + "/og.png"
+}
+
+// generateShareCard 生成文章的 Open Graph 图片，包含标题、站点名称和可选的封面图片
+func generateShareCard(post PostMetadata, config *BlogConfig, outputPath string) error {
+	fontFace, err := loadShareCardFont(config.ShareCardFont)
+	if err != nil {
+		return err
+	}
+	// 缺少字符时卡片上只会显示方框，不生成卡片
+	if missing := missingGlyphs(fontFace, post.Title+config.Title); missing != "" {
+		return fmt.Errorf("字体缺少字符 %q，请通过 SHARE_CARD_FONT 设置包含这些字符的字体", missing)
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, shareCardWidth, shareCardHeight))
+	if err := drawShareCardBackground(canvas, config); err != nil {
+		return err
+	}
+
+	textWidth := shareCardWidth - 2*shareCardPadding
+	if post.Cover != "" {
+		img, err := loadSiteImage(post.Cover, config)
+		if err != nil {
+			log.Printf("文章 %s 的封面图片无法用于分享卡片: %v", post.URI, err)
+		} else {
+			area := image.Rect(shareCardWidth-shareCardCover, 0, shareCardWidth, shareCardHeight)
+			drawCover(canvas, area, img)
+			textWidth -= shareCardCover
+		}
+	}
+
+	textColor, err := parseHexColor(config.ShareCardColor)
+	if err != nil {
+		return fmt.Errorf("SHARE_CARD_COLOR: %v", err)
+	}
+
+	titleFace, err := opentype.NewFace(fontFace, &opentype.FaceOptions{Size: 64, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return err
+	}
+	defer titleFace.Close()
+	siteFace, err := opentype.NewFace(fontFace, &opentype.FaceOptions{Size: 32, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return err
+	}
+	defer siteFace.Close()
+
+	// 标题最多三行，从上方开始绘制
+	drawer := &font.Drawer{Dst: canvas, Src: image.NewUniform(textColor), Face: titleFace}
+	lineHeight := titleFace.Metrics().Height.Ceil() + 12
+	y := shareCardPadding + titleFace.Metrics().Ascent.Ceil()
+	for _, line := range wrapText(drawer, post.Title, textWidth, 3) {
+		drawer.Dot = fixed.P(shareCardPadding, y)
+		drawer.DrawString(line)
+		y += lineHeight
+	}
+
+	// 站点名称绘制在左下角
+	drawer.Face = siteFace
+	drawer.Dot = fixed.P(shareCardPadding, shareCardHeight-shareCardPadding)
+	drawer.DrawString(config.Title)
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, canvas)
+}
+
+// loadShareCardFont 读取字体文件，未设置时使用内置的 Go Bold 字体，字体集合使用其中的第一个字体
+func loadShareCardFont(fontPath string) (*sfnt.Font, error) {
+	shareCardFontsMu.Lock()
+	defer shareCardFontsMu.Unlock()
+
+	if f, ok := shareCardFonts[fontPath]; ok {
+		return f, nil
+	}
+
+	data := gobold.TTF
+	if fontPath != "" {
+		var err error
+		if data, err = ioutil.ReadFile(fontPath); err != nil {
+			return nil, fmt.Errorf("读取分享卡片字体失败: %v", err)
+		}
+	}
+
+	collection, err := opentype.ParseCollection(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析分享卡片字体失败: %v", err)
+	}
+	f, err := collection.Font(0)
+	if err != nil {
+		return nil, fmt.Errorf("解析分享卡片字体失败: %v", err)
+	}
+	shareCardFonts[fontPath] = f
+	return f, nil
+}
+
+// missingGlyphs 返回字体中没有的字符
+func missingGlyphs(f *sfnt.Font, text string) string {
+	var buf sfnt.Buffer
+	var missing []rune
+	seen := make(map[rune]bool)
+	for _, r := range text {
+		if unicode.IsSpace(r) || seen[r] {
+			continue
+		}
+		seen[r] = true
+		if index, err := f.GlyphIndex(&buf, r); err == nil && index == 0 {
+			missing = append(missing, r)
+		}
+	}
+	return string(missing)
+}
+
+// drawShareCardBackground 使用纯色或图片填充背景，图片背景上覆盖一层半透明黑色以保证文字清晰
+func drawShareCardBackground(canvas *image.RGBA, config *BlogConfig) error {
+	background := config.ShareCardBackground
+	if strings.HasPrefix(background, "#") {
+		c, err := parseHexColor(background)
+		if err != nil {
+			return fmt.Errorf("SHARE_CARD_BACKGROUND: %v", err)
+		}
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+		return nil
+	}
+
+	img, err := loadSiteImage(background, config)
+	if err != nil {
+		return fmt.Errorf("SHARE_CARD_BACKGROUND: %v", err)
+	}
+	drawCover(canvas, canvas.Bounds(), img)
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.NRGBA{0, 0, 0, 115}), image.Point{}, draw.Over)
+	return nil
+}
+
+// loadSiteImage 读取站内图片，路径可以带有站点地址前缀
+func loadSiteImage(src string, config *BlogConfig) (image.Image, error) {
+	src = strings.TrimPrefix(src, config.URI)
+	if u, err := url.Parse(src); err != nil || u.IsAbs() {
+		return nil, fmt.Errorf("只支持站内图片: %s", src)
+	}
+	assetPath := strings.TrimPrefix(path.Clean("/"+src), "/")
+
+	data, err := ioutil.ReadFile(localAssetPath(assetPath))
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解码图片 %s 失败: %v", src, err)
+	}
+	return img, nil
+}
+
+// drawCover 将图片等比缩放并居中裁剪，铺满 area
+func drawCover(dst draw.Image, area image.Rectangle, img image.Image) {
+	bounds := img.Bounds()
+	crop := bounds
+	if bounds.Dx()*area.Dy() > bounds.Dy()*area.Dx() {
+		// 图片更宽，裁掉左右两侧
+		width := bounds.Dy() * area.Dx() / area.Dy()
+		crop.Min.X += (bounds.Dx() - width) / 2
+		crop.Max.X = crop.Min.X + width
+	} else {
+		height := bounds.Dx() * area.Dy() / area.Dx()
+		crop.Min.Y += (bounds.Dy() - height) / 2
+		crop.Max.Y = crop.Min.Y + height
+	}
+	draw.CatmullRom.Scale(dst, area, img, crop, draw.Src, nil)
+}
+
+// wrapText 按宽度将文字折行，拉丁文字在单词之间断开，中日韩文字可以在任意字符之间断开，
+// 超过 maxLines 行时截断并添加省略号
+func wrapText(drawer *font.Drawer, text string, width, maxLines int) []string {
+	limit := fixed.I(width)
+	var lines []string
+	line := ""
+	for _, token := range textTokens(text) {
+		candidate := line + token
+		if line != "" && drawer.MeasureString(candidate) > limit {
+			lines = append(lines, strings.TrimSpace(line))
+			line = strings.TrimLeftFunc(token, unicode.IsSpace)
+			continue
+		}
+		line = candidate
+	}
+	if strings.TrimSpace(line) != "" {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+
+	if len(lines) > maxLines {
+		last := []rune(lines[maxLines-1])
+		for len(last) > 0 && drawer.MeasureString(string(last)+"…") > limit {
+			last = last[:len(last)-1]
+		}
+		lines = append(lines[:maxLines-1], strings.TrimSpace(string(last))+"…")
+	}
+	return lines
+}
+
+// textTokens 将文字切分为折行的最小单位：带前导空格的单词或单个中日韩字符
+func textTokens(text string) []string {
+	var tokens []string
+	current := ""
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			if strings.TrimSpace(current) != "" {
+				tokens = append(tokens, current)
+				current = ""
+			}
+			current += " "
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || unicode.IsPunct(r) && r > unicode.MaxLatin1:
+			tokens = append(tokens, current+string(r))
+			current = ""
+		default:
+			current += string(r)
+		}
+	}
+	if current != "" {
+		tokens = append(tokens, current)
+	}
+	return tokens
+}
+
+// parseHexColor 解析 #rgb 或 #rrggbb 格式的颜色
+func parseHexColor(value string) (color.Color, error) {
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return nil, fmt.Errorf("无效的颜色 %s", value)
+	}
+	return color.RGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 255}, nil
+}
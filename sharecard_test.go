@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestWrapText(t *testing.T) {
+	// Face7x13 中每个字符宽 7 像素，宽度按字符数计算
+	drawer := &font.Drawer{Face: basicfont.Face7x13}
+	tests := []struct {
+		name     string
+		text     string
+		chars    int
+		maxLines int
+		want     []string
+	}{
+		{name: "一行放得下", text: "hello world", chars: 11, maxLines: 3, want: []string{"hello world"}},
+		{name: "在单词之间折行", text: "hello world", chars: 5, maxLines: 3, want: []string{"hello", "world"}},
+		{name: "中文在任意字符之间折行", text: "中文标题很长", chars: 4, maxLines: 3, want: []string{"中文标题", "很长"}},
+		{name: "中英混排", text: "Go 语言入门", chars: 3, maxLines: 3, want: []string{"Go", "语言入", "门"}},
+		{name: "超过行数时截断", text: "a b c d e f g h", chars: 5, maxLines: 2, want: []string{"a b c", "d e…"}},
+		{name: "空标题", text: "  ", chars: 5, maxLines: 2, want: nil},
+	}
+
+	for _, tt := range tests {
+		got := wrapText(drawer, tt.text, tt.chars*7, tt.maxLines)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: wrapText(%q) = %q，期望 %q", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTextTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "hello big world", want: []string{"hello", " big", " world"}},
+		{text: "Go 语言，入门 guide", want: []string{"Go", " 语", "言", "，", "入", "门", " guide"}},
+		{text: "カタカナ", want: []string{"カ", "タ", "カ", "ナ"}},
+	}
+
+	for _, tt := range tests {
+		if got := textTokens(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("textTokens(%q) = %q，期望 %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		value string
+		want  [4]uint32
+		ok    bool
+	}{
+		{value: "#ffffff", want: [4]uint32{0xffff, 0xffff, 0xffff, 0xffff}, ok: true},
+		{value: "#f00", want: [4]uint32{0xffff, 0, 0, 0xffff}, ok: true},
+		{value: "#1f2937", want: [4]uint32{0x1f1f, 0x2929, 0x3737, 0xffff}, ok: true},
+		{value: "#ggg"},
+		{value: "#12345"},
+	}
+
+	for _, tt := range tests {
+		c, err := parseHexColor(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseHexColor(%q) 错误为 %v", tt.value, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		r, g, b, a := c.RGBA()
+		if got := [4]uint32{r, g, b, a}; got != tt.want {
+			t.Errorf("parseHexColor(%q) = %v，期望 %v", tt.value, got, tt.want)
+		}
+	}
+}
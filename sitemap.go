@@ -3,42 +3,275 @@ package main
 import (
 	"encoding/xml"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
+// 单个站点地图文件最多包含的地址数量
+const maxSitemapURLs = 50000
+
 type URLSet struct {
 	XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	Image   string   `xml:"xmlns:image,attr,omitempty"`
 	Urls    []URL    `xml:"url"`
 }
 
 type URL struct {
-	Loc        string `xml:"loc"`
-	LastMod    string `xml:"lastmod"`
-	ChangeFreq string `xml:"changefreq"`
+	Loc        string         `xml:"loc"`
+	LastMod    string         `xml:"lastmod,omitempty"`
+	ChangeFreq string         `xml:"changefreq,omitempty"`
+	Images     []SitemapImage `xml:"image:image"`
 }
 
+// SitemapImage 是站点地图图片扩展中的一张图片
+type SitemapImage struct {
+	Loc string `xml:"image:loc"`
+}
+
+// SitemapIndex 在地址过多时列出拆分后的各个站点地图文件
+type SitemapIndex struct {
+	XMLName  xml.Name         `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []SitemapPointer `xml:"sitemap"`
+}
+
+type SitemapPointer struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// 各类页面的更新频率
+const (
+	changeFreqHome = "always"
+	changeFreqList = "daily"
+	changeFreqPost = "weekly"
+	changeFreqPage = "monthly"
+)
+
+// generateSitemap 生成包含首页、文章、标签、分类、作者、分页、搜索页面和 static 目录中独立页面的站点地图，
+// 地址超过 50000 个时拆分为多个文件，outputPath 写入站点地图索引。
+// 首页分页即按时间排列的文章归档，没有单独的归档页面
 func generateSitemap(posts []PostMetadata, blogconfigs *BlogConfig, outputPath string) error {
-	urlSet := URLSet{}
+	urls := sitemapURLs(posts, blogconfigs)
 
-	// 包含主页
-	homeUrl := URL{
-		Loc:        blogconfigs.URI,
-		LastMod:    time.Now().Format(time.RFC3339),
-		ChangeFreq: "always",
+	if len(urls) <= maxSitemapURLs {
+		return writeSitemapFile(urls, blogconfigs.SitemapImages, outputPath)
 	}
-	urlSet.Urls = append(urlSet.Urls, homeUrl)
 
-	// 为每篇博客添加 URL 信息
+	index := SitemapIndex{}
+	lastMod := time.Now().UTC().Format(time.RFC3339)
+	for i := 0; i*maxSitemapURLs < len(urls); i++ {
+		end := (i + 1) * maxSitemapURLs
+		if end > len(urls) {
+			end = len(urls)
+		}
+
+		name := fmt.Sprintf("sitemap-%d.xml", i+1)
+		if err := writeSitemapFile(urls[i*maxSitemapURLs:end], blogconfigs.SitemapImages, filepath.Join(filepath.Dir(outputPath), name)); err != nil {
+			return err
+		}
+		index.Sitemaps = append(index.Sitemaps, SitemapPointer{Loc: blogconfigs.URI + "/" + name, LastMod: lastMod})
+	}
+	return writeXMLFile(index, outputPath)
+}
+
+// sitemapURLs 收集站点中需要出现在站点地图中的地址
+func sitemapURLs(posts []PostMetadata, config *BlogConfig) []URL {
+	var urls []URL
+	lastMod := ""
 	for _, post := range posts {
-		url := URL{
-			Loc:        blogconfigs.URI + "/" + post.URI + "/",
-			LastMod:    post.Date,
-			ChangeFreq: "weekly",
+		if date := sitemapDate(post.Date); date > lastMod {
+			lastMod = date
 		}
-		urlSet.Urls = append(urlSet.Urls, url)
 	}
 
+	// 首页及分页
+	if !noIndexPage(config, "index") {
+		home := paginatedURLs(config.URI+"/", len(posts), lastMod)
+		home[0].ChangeFreq = changeFreqHome
+		urls = append(urls, home...)
+	}
+
+	// 文章
+	for _, post := range posts {
+		if post.NoIndex || (post.InSitemap != nil && !*post.InSitemap) {
+			continue
+		}
+		postURL := config.URI + "/" + post.URI + "/"
+		entry := URL{Loc: postURL, LastMod: sitemapDate(post.Date), ChangeFreq: changeFreqPost}
+		if config.SitemapImages {
+			entry.Images = postImages(post, postURL)
+		}
+		urls = append(urls, entry)
+	}
+
+	// 标签、分类和作者页面及分页，最后修改时间取其中最新的文章
+	urls = append(urls, groupedURLs(posts, config, "tags", "tag", func(post PostMetadata) []string { return post.Tags })...)
+	urls = append(urls, groupedURLs(posts, config, "categories", "category", func(post PostMetadata) []string {
+		if post.Category == "" {
+			return nil
+		}
+		return []string{post.Category}
+	})...)
+	// 旧版主题没有作者页面模板，不会生成作者页面
+	urls = append(urls, groupedURLs(posts, config, "authors", "author", func(post PostMetadata) []string {
+		if post.AuthorInfo == nil || !hasAuthorTemplate("./data/templates") {
+			return nil
+		}
+		return []string{post.AuthorInfo.ID}
+	})...)
+
+	if !noIndexPage(config, "search") {
+		urls = append(urls, URL{Loc: config.URI + "/search/", LastMod: lastMod, ChangeFreq: changeFreqList})
+	}
+	urls = append(urls, staticPageURLs(config, "./data/static")...)
+	return urls
+}
+
+// staticPageURLs 返回 static 目录中的 HTML 独立页面，页面自身带有 noindex 的 robots 标签时不列出
+func staticPageURLs(config *BlogConfig, dir string) []URL {
+	var urls []URL
+	filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.EqualFold(filepath.Ext(file), ".html") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || staticPageNoIndex(file) {
+			return nil
+		}
+		loc := filepath.ToSlash(rel)
+		if path.Base(loc) == "index.html" {
+			loc = strings.TrimSuffix(loc, "index.html")
+		}
+		urls = append(urls, URL{Loc: config.URI + "/" + loc, LastMod: info.ModTime().UTC().Format("2006-01-02"), ChangeFreq: changeFreqPage})
+		return nil
+	})
+	return urls
+}
+
+// staticPageNoIndex 判断页面的 robots 标签是否禁止收录
+func staticPageNoIndex(file string) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		return false
+	}
+	noindex := false
+	doc.Find(`meta[name="robots"]`).Each(func(i int, s *goquery.Selection) {
+		content, _ := s.Attr("content")
+		for _, value := range strings.Split(content, ",") {
+			if v := strings.ToLower(strings.TrimSpace(value)); v == "noindex" || v == "none" {
+				noindex = true
+			}
+		}
+	})
+	return noindex
+}
+
+// groupedURLs 按 keys 返回的名称将文章分组，为每组生成页面及分页地址，pageType 在 NOINDEX_PAGES 中时不生成
+func groupedURLs(posts []PostMetadata, config *BlogConfig, section, pageType string, keys func(PostMetadata) []string) []URL {
+	if noIndexPage(config, pageType) {
+		return nil
+	}
+	counts := make(map[string]int)
+	lastMods := make(map[string]string)
+	indexed := make(map[string]bool)
+	for _, post := range posts {
+		for _, key := range keys(post) {
+			if key == "" {
+				continue
+			}
+			counts[key]++
+			// 只包含 noindex 文章的标签、分类或作者页面不进入站点地图
+			if !post.NoIndex {
+				indexed[key] = true
+			}
+			if date := sitemapDate(post.Date); date > lastMods[key] {
+				lastMods[key] = date
+			}
+		}
+	}
+
+	var names []string
+	for name := range indexed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var urls []URL
+	for _, name := range names {
+		urls = append(urls, paginatedURLs(sectionURL(config, section, name), counts[name], lastMods[name])...)
+	}
+	return urls
+}
+
+// paginatedURLs 返回列表页面的首页和 page/N/ 分页地址
+func paginatedURLs(base string, count int, lastMod string) []URL {
+	urls := []URL{{Loc: base, LastMod: lastMod, ChangeFreq: changeFreqList}}
+	totalPages := (count + postsPerPage - 1) / postsPerPage
+	for page := 2; page <= totalPages; page++ {
+		urls = append(urls, URL{Loc: fmt.Sprintf("%spage/%d/", base, page), LastMod: lastMod, ChangeFreq: changeFreqList})
+	}
+	return urls
+}
+
+// postImages 返回文章正文中图片的完整地址，不包含生成的缩放图片
+func postImages(post PostMetadata, postURL string) []SitemapImage {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(post.ContentHTML))
+	if err != nil {
+		return nil
+	}
+	base, err := url.Parse(postURL)
+	if err != nil {
+		return nil
+	}
+
+	var images []SitemapImage
+	seen := make(map[string]bool)
+	doc.Find("img[src]").Each(func(i int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		ref, err := url.Parse(strings.TrimSpace(src))
+		if err != nil || (ref.Scheme != "" && ref.Scheme != "http" && ref.Scheme != "https") {
+			return
+		}
+		loc := base.ResolveReference(ref).String()
+		if !seen[loc] {
+			seen[loc] = true
+			images = append(images, SitemapImage{Loc: loc})
+		}
+	})
+	return images
+}
+
+// sitemapDate 返回可用作 lastmod 的日期，无效的日期返回空字符串
+func sitemapDate(date string) string {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return ""
+	}
+	return date
+}
+
+// writeSitemapFile 写入一个站点地图文件
+func writeSitemapFile(urls []URL, images bool, outputPath string) error {
+	urlSet := URLSet{Urls: urls}
+	if images {
+		urlSet.Image = "http://www.google.com/schemas/sitemap-image/1.1"
+	}
+	return writeXMLFile(urlSet, outputPath)
+}
+
+// writeXMLFile 将数据编码为带 XML 声明的文件
+func writeXMLFile(v interface{}, outputPath string) error {
 	file, err := os.Create(outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
@@ -52,7 +285,7 @@ func generateSitemap(posts []PostMetadata, blogconfigs *BlogConfig, outputPath s
 
 	encoder := xml.NewEncoder(file)
 	encoder.Indent("", "  ")
-	if err := encoder.Encode(urlSet); err != nil {
+	if err := encoder.Encode(v); err != nil {
 		return fmt.Errorf("failed to encode sitemap: %w", err)
 	}
 
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readSitemapXML 读取并解析站点地图文件
+func readSitemapXML(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		t.Fatalf("解析 %s 失败: %v", path, err)
+	}
+}
+
+func TestGenerateSitemapSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		posts int
+		files []int // 每个站点地图文件中的地址数量，为空时只输出一个 urlset
+	}{
+		// 首页分页 1 个 + 文章 3 篇 + 搜索页 1 个
+		{name: "不拆分", posts: 3},
+		// 首页分页 4600 个 + 文章 46000 篇 + 搜索页 1 个
+		{name: "拆分为两个文件", posts: 46000, files: []int{maxSitemapURLs, 601}},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		config := &BlogConfig{URI: "https://example.com"}
+		posts := make([]PostMetadata, tt.posts)
+		for i := range posts {
+			posts[i] = PostMetadata{URI: fmt.Sprintf("post-%d", i), Date: "2024-01-02"}
+		}
+
+		output := filepath.Join(dir, "sitemap.xml")
+		if err := generateSitemap(posts, config, output); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if len(tt.files) == 0 {
+			var urlSet URLSet
+			readSitemapXML(t, output, &urlSet)
+			if len(urlSet.Urls) != tt.posts+2 {
+				t.Errorf("%s: 站点地图中有 %d 个地址，期望 %d 个", tt.name, len(urlSet.Urls), tt.posts+2)
+			}
+			continue
+		}
+
+		var index SitemapIndex
+		readSitemapXML(t, output, &index)
+		if len(index.Sitemaps) != len(tt.files) {
+			t.Fatalf("%s: 索引中有 %d 个站点地图，期望 %d 个", tt.name, len(index.Sitemaps), len(tt.files))
+		}
+		for i, count := range tt.files {
+			name := fmt.Sprintf("sitemap-%d.xml", i+1)
+			if loc := index.Sitemaps[i].Loc; loc != "https://example.com/"+name {
+				t.Errorf("%s: 索引中第 %d 个地址为 %s", tt.name, i+1, loc)
+			}
+			var urlSet URLSet
+			readSitemapXML(t, filepath.Join(dir, name), &urlSet)
+			if len(urlSet.Urls) != count {
+				t.Errorf("%s: %s 中有 %d 个地址，期望 %d 个", tt.name, name, len(urlSet.Urls), count)
+			}
+		}
+	}
+}
+
+func TestSitemapURLs(t *testing.T) {
+	noSitemap := false
+	posts := []PostMetadata{
+		{URI: "a", Date: "2024-03-01", Tags: []string{"go"}, Category: "技术"},
+		{URI: "b", Date: "2024-02-01", Tags: []string{"go", "私密"}, NoIndex: true},
+		{URI: "c", Date: "日期无效", InSitemap: &noSitemap},
+		{URI: "d", Date: "2024-01-01", Tags: []string{"私密"}, NoIndex: true},
+	}
+
+	tests := []struct {
+		name         string
+		noIndexPages []string
+		want         []URL
+	}{
+		{
+			name: "默认",
+			want: []URL{
+				{Loc: "https://example.com/", LastMod: "2024-03-01", ChangeFreq: changeFreqHome},
+				{Loc: "https://example.com/a/", LastMod: "2024-03-01", ChangeFreq: changeFreqPost},
+				{Loc: "https://example.com/tags/go/", LastMod: "2024-03-01", ChangeFreq: changeFreqList},
+				{Loc: "https://example.com/categories/%E6%8A%80%E6%9C%AF/", LastMod: "2024-03-01", ChangeFreq: changeFreqList},
+				{Loc: "https://example.com/search/", LastMod: "2024-03-01", ChangeFreq: changeFreqList},
+			},
+		},
+		{
+			name:         "NOINDEX_PAGES",
+			noIndexPages: []string{"tag", "search"},
+			want: []URL{
+				{Loc: "https://example.com/", LastMod: "2024-03-01", ChangeFreq: changeFreqHome},
+				{Loc: "https://example.com/a/", LastMod: "2024-03-01", ChangeFreq: changeFreqPost},
+				{Loc: "https://example.com/categories/%E6%8A%80%E6%9C%AF/", LastMod: "2024-03-01", ChangeFreq: changeFreqList},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		config := &BlogConfig{URI: "https://example.com", NoIndexPages: tt.noIndexPages}
+		got := sitemapURLs(posts, config)
+		// static 目录中的独立页面与测试无关
+		if len(got) > len(tt.want) {
+			got = got[:len(tt.want)]
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: sitemapURLs =\n%v\n期望\n%v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStaticPageURLs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"about.html":             "<p>关于</p>",
+		"friendlinks/index.html": "<html><head><title>友链</title></head></html>",
+		"draft/index.html":       `<html><head><meta name="robots" content="noindex, follow"></head></html>`,
+		"images/logo.png":        "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	for _, u := range staticPageURLs(&BlogConfig{URI: "https://example.com"}, dir) {
+		if u.ChangeFreq != changeFreqPage || u.LastMod == "" {
+			t.Errorf("%s 的 changefreq 或 lastmod 不正确: %+v", u.Loc, u)
+		}
+		got = append(got, u.Loc)
+	}
+	want := []string{"https://example.com/about.html", "https://example.com/friendlinks/"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("staticPageURLs = %v，期望 %v", got, want)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// moreMarker 匹配 <!--more--> 摘要分隔符，允许注释内有空格
+var moreMarker = regexp.MustCompile(`<!--\s*more\s*-->`)
+
+// splitSummary 从渲染后的 HTML 中提取摘要。
+// 存在 <!--more--> 时取其之前的内容，否则按 limit 个字符自动截取，返回值 hasMore 表示正文还有更多内容
+func splitSummary(contentHTML string, limit int) (summary string, hasMore bool) {
+	if loc := moreMarker.FindStringIndex(contentHTML); loc != nil {
+		// 分隔符可能位于未闭合的标签内部，重新解析一次以补全闭合标签
+		summary, _ = truncateHTML(contentHTML[:loc[0]], -1)
+		return summary, strings.TrimSpace(contentHTML[loc[1]:]) != ""
+	}
+	return truncateHTML(contentHTML, limit)
+}
+
+// truncateHTML 按可见字符数截取 HTML，保持标签完整闭合。
+// 中日韩文字按单个字符截断，拉丁文字不会从单词中间截断。limit 小于 0 时不截取
+func truncateHTML(source string, limit int) (string, bool) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(source), context)
+	if err != nil {
+		return "", false
+	}
+
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, node := range nodes {
+		root.AppendChild(node)
+	}
+
+	truncated := false
+	if limit >= 0 {
+		remaining := limit
+		truncated = pruneHTML(root, &remaining)
+	}
+
+	var builder strings.Builder
+	for node := root.FirstChild; node != nil; node = node.NextSibling {
+		if err := html.Render(&builder, node); err != nil {
+			return "", false
+		}
+	}
+	return builder.String(), truncated
+}
+
+// pruneHTML 遍历节点树，累计可见字符数，超出 remaining 后截断文本并移除之后的节点
+func pruneHTML(n *html.Node, remaining *int) bool {
+	truncated := false
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		if *remaining <= 0 {
+			if hasVisibleText(c) {
+				truncated = true
+			}
+			n.RemoveChild(c)
+			c = next
+			continue
+		}
+
+		switch c.Type {
+		case html.TextNode:
+			runes := []rune(c.Data)
+			if visibleLength(runes) > *remaining {
+				c.Data = cutText(runes, *remaining) + "…"
+				*remaining = 0
+				truncated = true
+			} else {
+				*remaining -= visibleLength(runes)
+			}
+		case html.ElementNode:
+			if pruneHTML(c, remaining) {
+				truncated = true
+			}
+		}
+		c = next
+	}
+	return truncated
+}
+
+// visibleLength 计算不含空白字符的字符数
+func visibleLength(runes []rune) int {
+	count := 0
+	for _, r := range runes {
+		if !unicode.IsSpace(r) {
+			count++
+		}
+	}
+	return count
+}
+
+// cutText 截取前 limit 个可见字符，如果截断点位于拉丁单词中间则回退到单词开头
+func cutText(runes []rune, limit int) string {
+	count := 0
+	end := 0
+	for i, r := range runes {
+		if !unicode.IsSpace(r) {
+			count++
+		}
+		if count == limit {
+			end = i + 1
+			break
+		}
+	}
+
+	if end < len(runes) && isWordRune(runes[end]) && isWordRune(runes[end-1]) {
+		for i := end - 1; i > 0; i-- {
+			if !isWordRune(runes[i-1]) {
+				end = i
+				break
+			}
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:end]), unicode.IsSpace)
+}
+
+// hasVisibleText 判断节点中是否包含非空白文本或图片
+func hasVisibleText(n *html.Node) bool {
+	if n.Type == html.TextNode {
+		return strings.TrimSpace(n.Data) != ""
+	}
+	if n.Type == html.ElementNode && n.DataAtom == atom.Img {
+		return true
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if hasVisibleText(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCJK 判断字符是否为中日韩文字
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// isWordRune 判断字符是否属于拉丁等以空格分词的单词
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isCJK(r)
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestTruncateHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		limit   int
+		want    string
+		hasMore bool
+	}{
+		{name: "不超过长度", source: "<p>短文</p>", limit: 10, want: "<p>短文</p>"},
+		{name: "中文按字截断", source: "<p>这是一段很长的中文内容</p>", limit: 4, want: "<p>这是一段…</p>", hasMore: true},
+		{name: "拉丁文字不截断单词", source: "<p>Hello wonderful world</p>", limit: 8, want: "<p>Hello…</p>", hasMore: true},
+		{name: "单个长单词", source: "<p>Supercalifragilistic</p>", limit: 5, want: "<p>Super…</p>", hasMore: true},
+		{name: "补全闭合标签", source: "<p><strong>粗体文字</strong>和普通文字</p><p>第二段</p>", limit: 3, want: "<p><strong>粗体文…</strong></p>", hasMore: true},
+		{name: "在段落边界截断", source: "<p>一二三</p><p>四五</p>", limit: 3, want: "<p>一二三</p>", hasMore: true},
+		{name: "之后只有图片", source: `<p>一二三</p><p><img src="a.png"></p>`, limit: 3, want: "<p>一二三</p>", hasMore: true},
+		{name: "之后只有空白", source: "<p>一二三</p>\n\n", limit: 3, want: "<p>一二三</p>"},
+		{name: "不限制长度", source: "<p>一二三</p><p>四五</p>", limit: -1, want: "<p>一二三</p><p>四五</p>"},
+	}
+
+	for _, tt := range tests {
+		got, hasMore := truncateHTML(tt.source, tt.limit)
+		if got != tt.want || hasMore != tt.hasMore {
+			t.Errorf("%s: truncateHTML(%q, %d) = %q, %v，期望 %q, %v", tt.name, tt.source, tt.limit, got, hasMore, tt.want, tt.hasMore)
+		}
+	}
+}
+
+func TestSplitSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		hasMore bool
+	}{
+		{name: "分隔符", content: "<p>a</p><!-- more --><p>b</p>", want: "<p>a</p>", hasMore: true},
+		{name: "段落中的分隔符", content: "<p>a<!--more-->b</p>", want: "<p>a</p>", hasMore: true},
+		{name: "分隔符之后没有内容", content: "<p>a</p><!--more-->\n", want: "<p>a</p>"},
+		{name: "自动截取", content: "<p>一二三四五</p>", want: "<p>一二…</p>", hasMore: true},
+	}
+
+	for _, tt := range tests {
+		got, hasMore := splitSummary(tt.content, 2)
+		if got != tt.want || hasMore != tt.hasMore {
+			t.Errorf("%s: splitSummary(%q) = %q, %v，期望 %q, %v", tt.name, tt.content, got, hasMore, tt.want, tt.hasMore)
+		}
+	}
+}
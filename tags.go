@@ -95,6 +95,13 @@ func GenerateTagPages(posts []PostMetadata, blogConfig *BlogConfig, templateDir,
 			return allTaggedPosts[i].Date > allTaggedPosts[j].Date
 		})
 
+		// 生成标签订阅
+		if err := generateSectionFeed(allTaggedPosts, blogConfig, "tags", tag, "标签: "+tag, outputDir); err != nil {
+			log.Printf("生成标签 %s 的订阅失败: %v", tag, err)
+		}
+		feedBase := sectionURL(blogConfig, "tags", tag) + "feed/"
+		links := append(feedLinks(blogConfig, feedBase, blogConfig.Title+" - 标签: "+tag), siteFeedLinks(blogConfig)...)
+
 		// 分页处理
 		totalPages := (len(allTaggedPosts) + postsPerPage - 1) / postsPerPage
 		for pageIndex := 0; pageIndex < totalPages; pageIndex++ {
@@ -124,10 +131,16 @@ func GenerateTagPages(posts []PostMetadata, blogConfig *BlogConfig, templateDir,
 				"BlogURI":         blogConfig.URI,
 				"BlogTags":        blogConfig.Tags,
 				"BlogAuthor":      blogConfig.Author,
+				"FeedLinks":       links,
+				"FeedURL":         primaryFeedURL(blogConfig, feedBase),
 				"Tag":             tag,
 				"Posts":           pagePosts,
 				"CurrentPage":     pageIndex + 1,
 				"TotalPages":      totalPages,
+				"SEO":             pageSEO(blogConfig, "标签: "+tag+" - "+blogConfig.Title, "所有 "+blogConfig.Title+" 中关于 "+tag+" 的文章", pageURL(sectionURL(blogConfig, "tags", tag), pageIndex+1)),
+				"Robots":          pageRobots(blogConfig, "tag", allTaggedPosts),
+				"Mermaid":         summariesHaveMermaid(pagePosts),
+				"MermaidScript":   blogConfig.MermaidScript,
 				"PageType":        "tag",
 			}
 
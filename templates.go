@@ -26,6 +26,7 @@ const BaseTemplate = `
 	  	<ul class="p-2">
 		 <li><a href="./new">新建</a></li>
          <li><a href="./article">列表</a></li>
+         <li><a href="./check">检查</a></li>
 	   </ul>
 		</li>
 		<li><a target="_blank" rel="noopener" href="./preview/">预览</a></li>
@@ -50,6 +51,7 @@ const BaseTemplate = `
 	 		<ul class="p-2">
 				<li><a href="./new">新建</a></li>
                 <li><a href="./article">列表</a></li>
+                <li><a href="./check">检查</a></li>
 	  		</ul>
         </details>
     </li>
@@ -157,6 +159,20 @@ const HomePageContent = `
 <div class="p-8 centered" style="user-select:none;">
     <h1  class="text-8xl font-bold">DaRM</h1>
 	<a></a>
+	<div class="stats shadow mt-8">
+		<div class="stat">
+			<div class="stat-title">文章</div>
+			<div class="stat-value">{{.Posts}}</div>
+		</div>
+		<div class="stat">
+			<div class="stat-title">总字数</div>
+			<div class="stat-value">{{.WordCount}}</div>
+		</div>
+		<div class="stat">
+			<div class="stat-title">总阅读时间</div>
+			<div class="stat-value">{{.ReadingTime}} 分钟</div>
+		</div>
+	</div>
 </div>
 `
 
@@ -198,6 +214,54 @@ function confirmDelete(title) {
 }
 </script>
 `
+const checkTemplate = `
+<div class="overflow-x-auto centered">
+  <div class="p-8" style="user-select:none;">
+    <h1  class="text-3xl font-bold text-center" >内容检查</h1>
+  </div>
+  {{if .Diagnostics}}
+  <table class="table">
+    <thead>
+      <tr>
+        <th>文件</th>
+        <th>级别</th>
+        <th>问题</th>
+      </tr>
+    </thead>
+    <tbody>
+	  {{range .Diagnostics}}
+      <tr class="hover">
+        <td>{{.File}}</td>
+        <td>{{if eq .Level "error"}}<span class="badge badge-error">错误</span>{{else}}<span class="badge badge-warning">警告</span>{{end}}</td>
+        <td>{{.Message}}</td>
+      </tr>
+	  {{end}}
+    </tbody>
+  </table>
+  {{else}}
+  <p class="text-center">没有发现问题。</p>
+  {{end}}
+  {{if .Snippets}}
+  <h2 class="text-xl font-bold text-center p-8">片段使用情况</h2>
+  <table class="table">
+    <thead>
+      <tr>
+        <th>片段</th>
+        <th>使用它的文章</th>
+      </tr>
+    </thead>
+    <tbody>
+	  {{range $file, $posts := .Snippets}}
+      <tr class="hover">
+        <td>{{$file}}</td>
+        <td>{{range $i, $post := $posts}}{{if $i}}, {{end}}{{$post}}{{end}}</td>
+      </tr>
+	  {{end}}
+    </tbody>
+  </table>
+  {{end}}
+</div>
+`
 const newArticle = `
 <div class="centered">
 <form id="newArticleForm" method="post" action="/new" class="p8">
@@ -224,6 +288,17 @@ const newArticle = `
 		<div class="form-control mb-4">
 		<input type="text" id="uri" name="uri" placeholder="URI" class="input input-bordered w-full max-w-xs" required>
 		</div>
+		<div class="form-control mb-4">
+		<input type="text" id="author" name="author" placeholder="作者 ID（可选）" class="input input-bordered w-full max-w-xs">
+		</div>
+		<div class="form-control mb-4">
+		<span class="label-text mb-2">封面（从 static 目录中选择）</span>
+		<div style="display:flex;flex-wrap:wrap;gap:0.5rem;max-width:20rem;">
+			<label><input type="radio" name="cover" value="" checked> 无封面</label>
+			{{ range .Images }}<label title="{{ . }}"><input type="radio" name="cover" value="{{ . }}"><img src="/media{{ . }}" alt="{{ . }}" loading="lazy" style="width:6rem;height:4rem;object-fit:cover;"></label>
+			{{ end }}
+		</div>
+		</div>
 		</div>
 	<div class="form-control mt-6" id="login-button-container">
 		<button type="submit" class="btn btn-wide primary">创建文章</button>
@@ -264,6 +339,9 @@ const settingsTemplate = `
     <div class="form-control mb-4">
         <input type="text" id="commenturi" name="commenturi" placeholder="评论系统地址" value="{{.CommentURI}}" class="input input-bordered w-full max-w-xs" required>
     </div>
+    <div class="form-control mb-4">
+        <input type="number" id="relatedposts" name="relatedposts" placeholder="相关文章数量" value="{{.RelatedPosts}}" min="0" class="input input-bordered w-full max-w-xs">
+    </div>
     <div class="form-control mt-6" id="save-button-container">
         <button type="submit" id="saveButton" class="btn btn-wide primary">保存</button>
     </div>
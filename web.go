@@ -8,10 +8,12 @@ import (
 	"html/template"
 	"io/ioutil"
 	"log"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -19,15 +21,16 @@ import (
 )
 
 type ConfigData struct {
-	UserName    string
-	Password    string
-	BlogTitle   string
-	Description string
-	Tags        string
-	URI         string
-	Author      string
-	Email       string
-	CommentURI  string
+	UserName     string
+	Password     string
+	BlogTitle    string
+	Description  string
+	Tags         string
+	URI          string
+	Author       string
+	Email        string
+	CommentURI   string
+	RelatedPosts string
 }
 
 // Article 数据结构，用于模板渲染
@@ -113,8 +116,28 @@ func indexHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 统计文章总数、总字数和总阅读时间
+	stats := struct {
+		Posts       int
+		WordCount   int
+		ReadingTime int
+	}{}
+	if config, err := LoadBlogConfig("./data/.env"); err != nil {
+		log.Printf("加载配置失败: %v", err)
+	} else if posts, err := ReadPostMetadata("./data/posts", config); err == nil {
+		stats.Posts = len(posts)
+		for _, post := range posts {
+			stats.WordCount += post.WordCount
+			stats.ReadingTime += post.ReadingTime
+		}
+	}
+
+	var homeContent strings.Builder
+	homeTmpl := template.Must(template.New("home").Parse(HomePageContent))
+	homeTmpl.Execute(&homeContent, stats)
+
 	t := template.Must(template.New("webpage").Parse(BaseTemplate))
-	t.Execute(w, map[string]interface{}{"Content": template.HTML(HomePageContent)})
+	t.Execute(w, map[string]interface{}{"Content": template.HTML(homeContent.String())})
 }
 func generateHandler(w http.ResponseWriter, r *http.Request) {
 	if !checkLogin(r) {
@@ -165,7 +188,12 @@ func articleHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	postMetadatas, err := ReadPostMetadata("./data/posts")
+	config, err := LoadBlogConfig("./data/.env")
+	if err != nil {
+		http.Error(w, "加载配置失败", http.StatusInternalServerError)
+		return
+	}
+	postMetadatas, err := ReadPostMetadata("./data/posts", config)
 	if err != nil {
 		http.Error(w, "服务器内部错误", http.StatusInternalServerError)
 		return
@@ -202,6 +230,23 @@ func newArticleHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method == "GET" {
+		// 列出媒体库中的图片供选择封面
+		images, err := listMediaImages()
+		if err != nil {
+			log.Printf("读取媒体库失败: %v", err)
+		}
+
+		newTmpl, err := template.New("new").Parse(newArticle)
+		if err != nil {
+			http.Error(w, "服务器内部错误", http.StatusInternalServerError)
+			return
+		}
+		var newContent strings.Builder
+		if err := newTmpl.Execute(&newContent, map[string]interface{}{"Images": images}); err != nil {
+			http.Error(w, "服务器内部错误", http.StatusInternalServerError)
+			return
+		}
+
 		baseTemplate, err := template.New("base").Parse(BaseTemplate)
 		if err != nil {
 			http.Error(w, "服务器内部错误", http.StatusInternalServerError)
@@ -209,7 +254,7 @@ func newArticleHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		baseTemplate.Execute(w, map[string]interface{}{
-			"Content": template.HTML(newArticle),
+			"Content": template.HTML(newContent.String()),
 		})
 
 	} else if r.Method == "POST" {
@@ -226,6 +271,8 @@ func newArticleHandler(w http.ResponseWriter, r *http.Request) {
 		tags := r.FormValue("tags")
 		date := r.FormValue("date")
 		uri := r.FormValue("uri")
+		author := r.FormValue("author")
+		cover := r.FormValue("cover")
 
 		// 创建并写入 Markdown 文件
 		filePath := filepath.Join("./data/posts", fmt.Sprintf("%s.md", title))
@@ -242,6 +289,11 @@ func newArticleHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		tagsString := fmt.Sprintf("[%s]", strings.Join(tagsArray, ", "))
 
+		coverLine := ""
+		if cover != "" {
+			coverLine = fmt.Sprintf("cover: %s\n\n", strconv.Quote(cover))
+		}
+
 		mdContent := strings.ReplaceAll(fmt.Sprintf(
 			`---
 
@@ -257,7 +309,9 @@ date: "%s"
 
 uri: "%s"
 
----`, title, description, category, tagsString, date, uri), "\r\n", "\n")
+author: "%s"
+
+%s---`, title, description, category, tagsString, date, uri, author, coverLine), "\r\n", "\n")
 
 		_, err = file.WriteString(mdContent)
 		if err != nil {
@@ -287,15 +341,16 @@ func settingsHandler(w http.ResponseWriter, r *http.Request) {
 
 		// 映射 .env 配置到 ConfigData 结构体
 		config := ConfigData{
-			UserName:    env["USER_NAME"],
-			Password:    env["PASS_WORD"], // 注意：出于安全考虑，通常不建议在前端展示密码
-			BlogTitle:   env["BLOG_TITLE"],
-			Description: env["BLOG_DESCRIPTION"],
-			Tags:        env["BLOG_TAGS"],
-			URI:         env["BLOG_URI"],
-			Author:      env["BLOG_AUTHOR"],
-			Email:       env["EMAIL"],
-			CommentURI:  env["COMMENT_URI"],
+			UserName:     env["USER_NAME"],
+			Password:     env["PASS_WORD"], // 注意：出于安全考虑，通常不建议在前端展示密码
+			BlogTitle:    env["BLOG_TITLE"],
+			Description:  env["BLOG_DESCRIPTION"],
+			Tags:         env["BLOG_TAGS"],
+			URI:          env["BLOG_URI"],
+			Author:       env["BLOG_AUTHOR"],
+			Email:        env["EMAIL"],
+			CommentURI:   env["COMMENT_URI"],
+			RelatedPosts: env["RELATED_POSTS"],
 		}
 
 		// 解析并执行模板
@@ -330,8 +385,11 @@ func settingsHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// 创建映射以存储更新的环境变量
-		envMap := make(map[string]string)
+		// 读取现有配置，保留表单中没有的配置项
+		envMap, err := godotenv.Read("./data/.env")
+		if err != nil {
+			envMap = make(map[string]string)
+		}
 		envMap["USER_NAME"] = r.FormValue("username")               // Form 中的 name 应为 "username"
 		envMap["PASS_WORD"] = r.FormValue("password")               // Form 中的 name 应为 "password"
 		envMap["BLOG_TITLE"] = r.FormValue("blogtitle")             // Form 中的 name 应为 "blogtitle"
@@ -341,8 +399,9 @@ func settingsHandler(w http.ResponseWriter, r *http.Request) {
 		envMap["BLOG_AUTHOR"] = r.FormValue("blogauthor")           // Form 中的 name 应为 "blogauthor"
 		envMap["EMAIL"] = r.FormValue("email")                      // Form 中的 name 应为 "email"
 		envMap["COMMENT_URI"] = r.FormValue("commenturi")           // Form 中的 name 应为 "commenturi"
+		envMap["RELATED_POSTS"] = r.FormValue("relatedposts")       // Form 中的 name 应为 "relatedposts"
 		// 保存更新后的配置
-		err := godotenv.Write(envMap, "./data/.env")
+		err = godotenv.Write(envMap, "./data/.env")
 		if err != nil {
 			http.Error(w, "保存设置错误", http.StatusInternalServerError)
 			return
@@ -597,6 +656,12 @@ func editHandler(w http.ResponseWriter, r *http.Request) {
 		title := r.URL.Query().Get("title") // 获取标题
 
 		filePath := filepath.Join(postsDir, title+".md")
+
+		// uri 改变时自动将旧 uri 记录到 aliases，避免旧链接失效
+		if oldContent, err := ioutil.ReadFile(filePath); err == nil {
+			editedContent = recordURIAlias(string(oldContent), editedContent)
+		}
+
 		if err := ioutil.WriteFile(filePath, []byte(editedContent), 0644); err != nil {
 			// 保存失败，重定向时带上失败的标志
 			http.Redirect(w, r, "/edit?title="+title+"&save=failed", http.StatusFound)
@@ -608,6 +673,55 @@ func editHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// checkHandler 显示内容检查报告
+func checkHandler(w http.ResponseWriter, r *http.Request) {
+	if !checkLogin(r) {
+		// 未登录，重定向到登录页
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	diagnostics, users, err := runContentCheck()
+	if err != nil {
+		http.Error(w, "检查内容失败", http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := template.New("check").Parse(checkTemplate)
+	if err != nil {
+		http.Error(w, "服务器内部错误", http.StatusInternalServerError)
+		return
+	}
+
+	var checkContent strings.Builder
+	if err := tmpl.Execute(&checkContent, map[string]interface{}{"Diagnostics": diagnostics, "Snippets": users}); err != nil {
+		http.Error(w, "服务器内部错误", http.StatusInternalServerError)
+		return
+	}
+
+	t := template.Must(template.New("webpage").Parse(BaseTemplate))
+	t.Execute(w, map[string]interface{}{"Content": template.HTML(checkContent.String())})
+}
+
+// mediaHandler 提供 static 目录中的文件，供后台媒体库预览
+func mediaHandler(w http.ResponseWriter, r *http.Request) {
+	if !checkLogin(r) {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/media/")
+	data, err := readMediaFile(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Write(data)
+}
+
 // 鉴权中间件
 func authMiddleware(c *gin.Context) {
 	// 假设我们通过查询参数 token 来简单实现鉴权
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PostRef 是对另一篇文章的简要引用
+type PostRef struct {
+	Title string
+	URI   string
+	Date  string
+}
+
+// wikiLinkPattern 匹配 [[目标]] 和 [[目标|显示文字]]
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(?:\|([^\[\]\n]+))?\]\]`)
+
+// buildWikiIndex 建立 URI、别名和标题到文章 URI 的索引，标题不区分大小写
+func buildWikiIndex(posts []PostMetadata) map[string]string {
+	index := make(map[string]string)
+	for _, post := range posts {
+		if post.URI == "" {
+			continue
+		}
+		index[strings.ToLower(post.Title)] = post.URI
+	}
+	for _, post := range posts {
+		for _, alias := range post.Aliases {
+			index[strings.Trim(alias, "/")] = post.URI
+		}
+	}
+	for _, post := range posts {
+		if post.URI != "" {
+			index[strings.Trim(post.URI, "/")] = post.URI
+		}
+	}
+	return index
+}
+
+// resolveWikiLinks 将代码块之外的 Wiki 链接替换为 Markdown 链接，返回无法解析的链接目标
+func resolveWikiLinks(markdown string, index map[string]string, config *BlogConfig) (string, []string) {
+	var unresolved []string
+
+	result := transformOutsideCode(markdown, func(text string, _ int) string {
+		return wikiLinkPattern.ReplaceAllStringFunc(text, func(match string) string {
+			parts := wikiLinkPattern.FindStringSubmatch(match)
+			target := strings.TrimSpace(parts[1])
+			label := strings.TrimSpace(parts[2])
+
+			// 支持 [[目标#标题]] 链接到文章中的某个标题
+			heading := ""
+			if i := strings.Index(target, "#"); i >= 0 {
+				target, heading = strings.TrimSpace(target[:i]), strings.TrimSpace(target[i+1:])
+			}
+			if label == "" {
+				label = target
+			}
+
+			uri, ok := index[strings.Trim(target, "/")]
+			if !ok {
+				uri, ok = index[strings.ToLower(target)]
+			}
+			if !ok {
+				unresolved = append(unresolved, target)
+				return `<span class="wikilink-missing">` + html.EscapeString(label) + `</span>`
+			}
+
+			href := config.URI + "/" + uri + "/"
+			if heading != "" {
+				href += "#" + url.QueryEscape(heading)
+			}
+			return "[" + escapeLinkText(label) + "](" + href + ")"
+		})
+	})
+
+	return result, unresolved
+}
+
+// escapeLinkText 转义链接文字中的方括号
+func escapeLinkText(text string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(text)
+}
+
+// computeBacklinks 根据正文中指向其他文章的链接，为每篇文章填充反向链接
+func computeBacklinks(posts []PostMetadata, config *BlogConfig) {
+	owners := make(map[string]int)
+	for i, post := range posts {
+		for _, alias := range post.Aliases {
+			owners[strings.Trim(alias, "/")] = i
+		}
+	}
+	for i, post := range posts {
+		owners[strings.Trim(post.URI, "/")] = i
+	}
+
+	for i := range posts {
+		posts[i].Backlinks = nil
+	}
+
+	for i, post := range posts {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(post.ContentHTML))
+		if err != nil {
+			continue
+		}
+
+		linked := make(map[int]bool)
+		doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+			href, _ := s.Attr("href")
+			path, ok := sitePath(href, config)
+			if !ok {
+				return
+			}
+			if target, exists := owners[path]; exists && target != i {
+				linked[target] = true
+			}
+		})
+
+		for target := range linked {
+			posts[target].Backlinks = append(posts[target].Backlinks, PostRef{Title: post.Title, URI: post.URI, Date: post.Date})
+		}
+	}
+}
+
+// transformOutsideCode 只对代码块和行内代码之外的文本调用 fn，offset 为文本在 markdown 中的字节位置
+func transformOutsideCode(markdown string, fn func(text string, offset int) string) string {
+	var builder strings.Builder
+	var text strings.Builder
+	start, pos := 0, 0
+	flush := func() {
+		builder.WriteString(transformOutsideInlineCode(text.String(), start, fn))
+		text.Reset()
+	}
+
+	fence := ""
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		lineStart := pos
+		pos += len(line)
+
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				flush()
+				fence = trimmed[:3]
+				builder.WriteString(line)
+				continue
+			}
+			if text.Len() == 0 {
+				start = lineStart
+			}
+			text.WriteString(line)
+			continue
+		}
+
+		builder.WriteString(line)
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			fence = ""
+		}
+	}
+	flush()
+
+	return builder.String()
+}
+
+// transformOutsideInlineCode 跳过由反引号包围的行内代码
+func transformOutsideInlineCode(text string, offset int, fn func(string, int) string) string {
+	var builder strings.Builder
+	for {
+		start := strings.Index(text, "`")
+		if start < 0 {
+			builder.WriteString(fn(text, offset))
+			return builder.String()
+		}
+
+		// 行内代码的结束标记需要与开始标记的反引号数量一致
+		ticks := 1
+		for start+ticks < len(text) && text[start+ticks] == '`' {
+			ticks++
+		}
+		delimiter := strings.Repeat("`", ticks)
+		end := strings.Index(text[start+ticks:], delimiter)
+		if end < 0 {
+			builder.WriteString(fn(text, offset))
+			return builder.String()
+		}
+		end += start + ticks + ticks
+
+		builder.WriteString(fn(text[:start], offset))
+		builder.WriteString(text[start:end])
+		text = text[end:]
+		offset += end
+	}
+}
+
+// wikiLinkDiagnostics 将无法解析的 Wiki 链接转换为诊断信息
+func wikiLinkDiagnostics(post PostMetadata, unresolved []string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, target := range unresolved {
+		diagnostics = append(diagnostics, Diagnostic{post.FileName, levelWarning, fmt.Sprintf("无法解析 Wiki 链接 [[%s]]", target)})
+	}
+	return diagnostics
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownImage = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	markdownLink  = regexp.MustCompile(`\]\([^)]*\)`)
+	htmlTag       = regexp.MustCompile(`<[^>]+>`)
+)
+
+// countWords 统计 Markdown 正文字数，中日韩文字按字计数，拉丁文字按单词计数。代码块和行内代码不计入字数
+func countWords(markdown string) (cjk int, latin int) {
+	var prose strings.Builder
+	transformOutsideCode(markdown, func(text string, offset int) string {
+		prose.WriteString(text)
+		prose.WriteString("\n")
+		return text
+	})
+
+	// 去掉图片、链接地址和 HTML 标签，避免将网址计入字数
+	text := markdownImage.ReplaceAllString(prose.String(), "")
+	text = markdownLink.ReplaceAllString(text, "]")
+	text = htmlTag.ReplaceAllString(text, "")
+
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			cjk++
+			inWord = false
+		case isWordRune(r):
+			if !inWord {
+				latin++
+				inWord = true
+			}
+		case r == '\'' || r == '’' || r == '-':
+			// 撇号和连字符不拆分单词
+		default:
+			inWord = false
+		}
+	}
+	return cjk, latin
+}
+
+// estimateReadingTime 按每分钟阅读的中日韩字数和拉丁单词数估算阅读分钟数，有内容时至少为 1 分钟
+func estimateReadingTime(cjk, latin, cjkSpeed, latinSpeed int) int {
+	if cjkSpeed <= 0 || latinSpeed <= 0 || cjk+latin == 0 {
+		return 0
+	}
+	seconds := cjk*60/cjkSpeed + latin*60/latinSpeed
+	minutes := (seconds + 59) / 60
+	if minutes < 1 {
+		minutes = 1
+	}
+	return minutes
+}
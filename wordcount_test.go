@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		markdown   string
+		cjk, latin int
+	}{
+		{markdown: "你好 world, it's well-known", cjk: 2, latin: 3},
+		{markdown: "看 [链接](https://example.com/a-b) 和 ![图](a.png)", cjk: 4},
+		{markdown: "说明\n\n```go\nfunc main() { fmt.Println(\"代码\") }\n```\n\n结束", cjk: 4},
+		{markdown: "调用 `fmt.Println` 输出", cjk: 4},
+	}
+
+	for _, tt := range tests {
+		cjk, latin := countWords(tt.markdown)
+		if cjk != tt.cjk || latin != tt.latin {
+			t.Errorf("countWords(%q) = %d, %d，期望 %d, %d", tt.markdown, cjk, latin, tt.cjk, tt.latin)
+		}
+	}
+}